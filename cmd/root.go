@@ -3,11 +3,14 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,9 +18,17 @@ import (
 
 	"nuke/internal/config"
 	"nuke/internal/deleter"
+	"nuke/internal/dupes"
 	"nuke/internal/filter"
+	"nuke/internal/helper"
+	"nuke/internal/preflight"
+	"nuke/internal/projects"
+	"nuke/internal/report"
+	"nuke/internal/runs"
 	"nuke/internal/scanner"
+	"nuke/internal/top"
 	"nuke/internal/trash"
+	"nuke/internal/tui"
 	"nuke/internal/utils"
 
 	"github.com/schollz/progressbar/v3"
@@ -43,12 +54,89 @@ var (
 	regexPattern string
 	noCountdown  bool
 	workers      int
+	hardDelete   bool
+	reportPath   string
+	retryPolicy  = deleter.DefaultRetryPolicy()
+	useSudo      bool
+	fixPerms     bool
+	strict       bool
+
+	oneFileSystem  bool
+	followSymlinks bool
+
+	gitIgnored         bool
+	untracked          bool
+	respectIgnoreFiles bool
+
+	whereExpr string
+	explain   bool
+
+	typeFilter  string
+	ownerFilter string
+	groupFilter string
+	permFilter  string
+	emptyOnly   bool
+	atimeFilter string
+	ctimeFilter string
+	minDepth    string
+	maxDepth    string
+
+	mimeFilter      string
+	containsPattern string
+	containsMaxSize string
+
+	keepPolicy = string(dupes.KeepOldest)
+	preferDirs []string
+
+	profileNames string
+
+	inactiveFor string
+	sortBy      = "size"
+
+	topLimit = 20
 )
 
 // Execute runs the main CLI logic
 func Execute() error {
 	args := os.Args[1:]
 
+	// Privileged helper started by --sudo
+	if len(args) > 0 && args[0] == "--helper" {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		return helper.Serve(ctx, os.Stdin, os.Stdout)
+	}
+
+	// Handle subcommands
+	if len(args) > 0 && args[0] == "resume" {
+		runIDs, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return handleResume(runIDs, config.LoadConfig())
+	}
+	if len(args) > 0 && args[0] == "dupes" {
+		targets, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return handleDupes(targets, config.LoadConfig())
+	}
+	if len(args) > 0 && args[0] == "projects" {
+		targets, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return handleProjects(targets, config.LoadConfig())
+	}
+	if len(args) > 0 && args[0] == "top" {
+		targets, err := parseArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return handleTop(targets, config.LoadConfig())
+	}
+
 	// Parse flags and get targets
 	targets, err := parseArgs(args)
 	if err != nil {
@@ -82,19 +170,29 @@ func Execute() error {
 	cfg := config.LoadConfig()
 
 	// Create filter options
-	filterOpts, err := createFilterOptions()
+	filterOpts, err := createFilterOptions(cfg)
 	if err != nil {
 		return fmt.Errorf("invalid filter options: %w", err)
 	}
 
+	// Cleanup profiles look for artifacts anywhere beneath the targets
+	if profileNames != "" {
+		recursive = true
+	}
+
 	// Scan targets and collect files
 	fmt.Println("🔍 Scanning targets...")
-	files, err := scanTargets(targets, filterOpts, cfg)
+	files, scan, err := scanTargets(targets, filterOpts, cfg)
 	if err != nil {
 		return fmt.Errorf("scan error: %w", err)
 	}
 
 	if len(files) == 0 {
+		printSkippedDirs(scan.skipped)
+		if len(scan.errors) > 0 {
+			printScanErrors(scan.errors)
+			return scanIncomplete(scan.errors)
+		}
 		fmt.Println("✅ No files match the specified criteria.")
 		return nil
 	}
@@ -104,6 +202,14 @@ func Execute() error {
 
 	// Display summary
 	displaySummary(files, totalSize)
+	printTargetUsage(scan.usage)
+	obstacles := preflight.Check(files)
+	printObstacles(obstacles)
+	printSkippedDirs(scan.skipped)
+	printScanErrors(scan.errors)
+	if explain {
+		printExplanations(files, filterOpts)
+	}
 
 	// Check for dangerous patterns
 	if err := checkDangerousPatterns(targets, files); err != nil {
@@ -112,17 +218,73 @@ func Execute() error {
 
 	// Dry run mode - just show what would be deleted
 	if dryRun {
-		fmt.Println("\n📋 DRY RUN - The following would be deleted:")
-		for _, f := range files {
-			fmt.Printf("  %s (%s)\n", f.Path, utils.FormatSize(f.Size))
-		}
-		fmt.Println("\n✅ Dry run complete. No files were modified.")
+		printDryRun(files, obstacles)
+		return scanIncomplete(scan.errors)
+	}
+
+	return confirmAndDelete(files, cfg, treeOptions(filterOpts, cfg, scan.skipped), obstacles, nil, scan.errors)
+}
+
+// treeOptions returns how recursively scanned trees are deleted: their
+// planned entries are hard-deleted with the fast path or trashed as single
+// entries, honoring the same protected paths. The tree paths never
+// follow symlinks, so they are not used when the scan did.
+func treeOptions(filterOpts *filter.Options, cfg *config.Config, skipped []scanner.Skipped) *deleter.TreeOptions {
+	if shred || followSymlinks {
 		return nil
 	}
+	notEntered := make(map[string]bool, len(skipped))
+	for _, s := range skipped {
+		notEntered[s.Path] = true
+	}
+	return &deleter.TreeOptions{
+		Filter: filterOpts,
+		Protected: func(path string) bool {
+			return !force && cfg.IsProtected(path)
+		},
+		Skip: func(path string) bool {
+			return notEntered[path]
+		},
+	}
+}
+
+// verifyFunc re-checks planned files right before they are deleted and
+// returns the ones that may still be deleted
+type verifyFunc func([]scanner.FileInfo) []scanner.FileInfo
+
+// confirmAndDelete asks for confirmation as the flags require and deletes
+// the planned files. obstacles are those preflight.Check found in files;
+// verify, if set, runs once everything is confirmed.
+func confirmAndDelete(files []scanner.FileInfo, cfg *config.Config, treeOpts *deleter.TreeOptions, obstacles []preflight.Obstacle, verify verifyFunc, scanErrs []scanner.ScanError) error {
+	// Strict mode - never act on an incomplete plan
+	if strict && len(scanErrs) > 0 {
+		return fmt.Errorf("scan incomplete (%d paths could not be read); nothing was deleted (--strict)", len(scanErrs))
+	}
 
-	// Interactive mode - ask for each file
+	// Interactive mode - review the plan full screen on a terminal, or ask
+	// for each file otherwise
 	if interactive {
-		return handleInteractiveDelete(files, cfg)
+		if !tui.Available() {
+			if err := handleInteractiveDelete(files, cfg, obstacles, verify); err != nil {
+				return err
+			}
+			return scanIncomplete(scanErrs)
+		}
+		selected, err := tui.Review(files)
+		if errors.Is(err, tui.ErrCancelled) {
+			fmt.Println("❌ Operation cancelled.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("review failed: %w", err)
+		}
+		if len(selected) == 0 {
+			fmt.Println("❌ Nothing selected.")
+			return nil
+		}
+		files = selected
+		obstacles = preflight.Only(obstacles, files)
+		displaySummary(files, calculateTotalSize(files))
 	}
 
 	// Standard confirmation
@@ -142,7 +304,10 @@ func Execute() error {
 	}
 
 	// Perform deletion
-	return performDeletion(files, cfg)
+	if err := performDeletion(files, cfg, treeOpts, obstacles, verify); err != nil {
+		return err
+	}
+	return scanIncomplete(scanErrs)
 }
 
 // parseArgs parses command line arguments and returns targets
@@ -176,6 +341,66 @@ func parseArgs(args []string) ([]string, error) {
 			showTrash = true
 		case arg == "--no-countdown":
 			noCountdown = true
+		case arg == "--sudo":
+			useSudo = true
+		case arg == "--fix-perms":
+			fixPerms = true
+		case arg == "--strict":
+			strict = true
+		case arg == "--one-file-system":
+			oneFileSystem = true
+		case arg == "--follow-symlinks":
+			followSymlinks = true
+		case arg == "--gitignored":
+			gitIgnored = true
+		case arg == "--untracked":
+			untracked = true
+		case arg == "--respect-ignore-files":
+			respectIgnoreFiles = true
+		case arg == "--explain":
+			explain = true
+		case strings.HasPrefix(arg, "--where="):
+			whereExpr = strings.TrimPrefix(arg, "--where=")
+		case arg == "--empty":
+			emptyOnly = true
+		case strings.HasPrefix(arg, "--type="):
+			typeFilter = strings.TrimPrefix(arg, "--type=")
+		case strings.HasPrefix(arg, "--owner="):
+			ownerFilter = strings.TrimPrefix(arg, "--owner=")
+		case strings.HasPrefix(arg, "--group="):
+			groupFilter = strings.TrimPrefix(arg, "--group=")
+		case strings.HasPrefix(arg, "--perm="):
+			permFilter = strings.TrimPrefix(arg, "--perm=")
+		case strings.HasPrefix(arg, "--atime="):
+			atimeFilter = strings.TrimPrefix(arg, "--atime=")
+		case strings.HasPrefix(arg, "--ctime="):
+			ctimeFilter = strings.TrimPrefix(arg, "--ctime=")
+		case strings.HasPrefix(arg, "--min-depth="):
+			minDepth = strings.TrimPrefix(arg, "--min-depth=")
+		case strings.HasPrefix(arg, "--max-depth="):
+			maxDepth = strings.TrimPrefix(arg, "--max-depth=")
+		case strings.HasPrefix(arg, "--limit="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--limit="))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid --limit value: %s", strings.TrimPrefix(arg, "--limit="))
+			}
+			topLimit = n
+		case strings.HasPrefix(arg, "--inactive="):
+			inactiveFor = strings.TrimPrefix(arg, "--inactive=")
+		case strings.HasPrefix(arg, "--sort="):
+			sortBy = strings.TrimPrefix(arg, "--sort=")
+		case strings.HasPrefix(arg, "--profile="):
+			profileNames = strings.TrimPrefix(arg, "--profile=")
+		case strings.HasPrefix(arg, "--keep="):
+			keepPolicy = strings.TrimPrefix(arg, "--keep=")
+		case strings.HasPrefix(arg, "--prefer="):
+			preferDirs = append(preferDirs, strings.TrimPrefix(arg, "--prefer="))
+		case strings.HasPrefix(arg, "--mime="):
+			mimeFilter = strings.TrimPrefix(arg, "--mime=")
+		case strings.HasPrefix(arg, "--contains="):
+			containsPattern = strings.TrimPrefix(arg, "--contains=")
+		case strings.HasPrefix(arg, "--contains-max-size="):
+			containsMaxSize = strings.TrimPrefix(arg, "--contains-max-size=")
 		case strings.HasPrefix(arg, "--restore="):
 			restoreFile = strings.TrimPrefix(arg, "--restore=")
 		case strings.HasPrefix(arg, "--older-than="):
@@ -190,6 +415,26 @@ func parseArgs(args []string) ([]string, error) {
 			include = append(include, strings.TrimPrefix(arg, "--include="))
 		case strings.HasPrefix(arg, "--regex="):
 			regexPattern = strings.TrimPrefix(arg, "--regex=")
+		case strings.HasPrefix(arg, "--report="):
+			reportPath = strings.TrimPrefix(arg, "--report=")
+		case strings.HasPrefix(arg, "--on-busy="):
+			policy, err := deleter.ParseBusyPolicy(strings.TrimPrefix(arg, "--on-busy="))
+			if err != nil {
+				return nil, fmt.Errorf("invalid --on-busy value: %w", err)
+			}
+			retryPolicy.OnBusy = policy
+		case strings.HasPrefix(arg, "--retries="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--retries="))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid --retries value: %s", strings.TrimPrefix(arg, "--retries="))
+			}
+			retryPolicy.Attempts = n + 1
+		case strings.HasPrefix(arg, "--retry-backoff="):
+			backoff, err := time.ParseDuration(strings.TrimPrefix(arg, "--retry-backoff="))
+			if err != nil || backoff < 0 {
+				return nil, fmt.Errorf("invalid --retry-backoff value: %s", strings.TrimPrefix(arg, "--retry-backoff="))
+			}
+			retryPolicy.Backoff = backoff
 		case strings.HasPrefix(arg, "--workers="):
 			//nolint:errcheck // Invalid worker count falls back to default
 			fmt.Sscanf(strings.TrimPrefix(arg, "--workers="), "%d", &workers)
@@ -205,14 +450,63 @@ func parseArgs(args []string) ([]string, error) {
 		workers = 8
 	}
 
+	hardDelete = os.Getenv("NUKE_NO_TRASH") == "1"
+
 	return targets, nil
 }
 
-// createFilterOptions creates filter options from CLI flags
-func createFilterOptions() (*filter.Options, error) {
-	opts := &filter.Options{
-		Exclude: exclude,
-		Include: include,
+// createFilterOptions creates filter options from CLI flags and the
+// excludes of the config file
+func createFilterOptions(cfg *config.Config) (*filter.Options, error) {
+	opts := &filter.Options{}
+
+	// Config excludes come first, so flags can re-include with "!"
+	excludes, err := filter.ParsePatterns(append(append([]string{}, cfg.Exclude...), exclude...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --exclude value: %w", err)
+	}
+	opts.Exclude = excludes
+
+	includes, err := filter.ParsePatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --include value: %w", err)
+	}
+	opts.Include = includes
+
+	if gitIgnored || untracked || respectIgnoreFiles {
+		opts.Ignore = &filter.Ignore{GitIgnored: gitIgnored, Untracked: untracked, NukeIgnore: respectIgnoreFiles}
+	}
+
+	// Resolve cleanup profiles
+	if profileNames != "" {
+		var custom []filter.Profile
+		for _, p := range cfg.Profiles {
+			custom = append(custom, filter.Profile{Name: p.Name, Manifests: p.Manifests, Artifacts: p.Artifacts})
+		}
+		profiles, err := filter.LookupProfiles(profileNames, custom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --profile value: %w", err)
+		}
+		opts.Profiles = filter.NewProfiles(profiles)
+
+		// Selecting artifacts lifts a default protection such as
+		// node_modules
+		for _, name := range filter.ArtifactNames(profiles) {
+			cfg.UnprotectDefault(name)
+		}
+	}
+
+	// Parse the filter expression
+	if whereExpr != "" {
+		expr, err := filter.ParseExpr(whereExpr)
+		if err != nil {
+			var perr *filter.ParseError
+			if errors.As(err, &perr) {
+				return nil, fmt.Errorf("invalid --where expression: %w\n\n    %s", err, strings.ReplaceAll(perr.Context(), "\n", "\n    "))
+			}
+			return nil, fmt.Errorf("invalid --where expression: %w", err)
+		}
+		opts.Where = expr
 	}
 
 	// Parse older-than filter
@@ -245,6 +539,72 @@ func createFilterOptions() (*filter.Options, error) {
 		opts.SizeOp = op
 	}
 
+	// Parse access and change time filters
+	if atimeFilter != "" {
+		before, after, err := parseTimeFilter(atimeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --atime value: %w", err)
+		}
+		opts.AccessedBefore, opts.AccessedAfter = before, after
+	}
+	if ctimeFilter != "" {
+		before, after, err := parseTimeFilter(ctimeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --ctime value: %w", err)
+		}
+		opts.ChangedBefore, opts.ChangedAfter = before, after
+	}
+
+	// Parse type, ownership and permission filters
+	if typeFilter != "" {
+		types, err := filter.ParseTypes(typeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --type value: %w", err)
+		}
+		opts.Types = types
+	}
+	if ownerFilter != "" {
+		uid, err := filter.LookupOwner(ownerFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --owner value: %w", err)
+		}
+		opts.UID = &uid
+	}
+	if groupFilter != "" {
+		gid, err := filter.LookupGroup(groupFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --group value: %w", err)
+		}
+		opts.GID = &gid
+	}
+	if permFilter != "" {
+		bits, op, err := filter.ParsePerm(permFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --perm value: %w", err)
+		}
+		opts.Perm, opts.PermOp = bits, op
+	}
+	opts.Empty = emptyOnly
+
+	// Parse depth limits
+	if minDepth != "" {
+		depth, err := strconv.Atoi(minDepth)
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("invalid --min-depth value: %s", minDepth)
+		}
+		opts.MinDepth = depth
+	}
+	if maxDepth != "" {
+		depth, err := strconv.Atoi(maxDepth)
+		if err != nil || depth < 1 {
+			return nil, fmt.Errorf("invalid --max-depth value: %s (must be at least 1)", maxDepth)
+		}
+		opts.MaxDepth = depth
+	}
+	if opts.MaxDepth > 0 && opts.MinDepth > opts.MaxDepth {
+		return nil, fmt.Errorf("--min-depth cannot be greater than --max-depth")
+	}
+
 	// Compile regex pattern
 	if regexPattern != "" {
 		re, err := regexp.Compile(regexPattern)
@@ -254,18 +614,70 @@ func createFilterOptions() (*filter.Options, error) {
 		opts.Regex = re
 	}
 
+	// Parse content filters
+	if mimeFilter != "" {
+		opts.MIME = filter.ParseMIME(mimeFilter)
+	}
+	if containsPattern != "" {
+		re, err := regexp.Compile(containsPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --contains pattern: %w", err)
+		}
+		opts.Contains = re
+		opts.ContainsMaxSize = filter.DefaultContainsMaxSize
+	}
+	if containsMaxSize != "" {
+		size, err := utils.ParseSize(containsMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --contains-max-size value: %w", err)
+		}
+		opts.ContainsMaxSize = size
+	}
+
 	return opts, nil
 }
 
-// scanTargets scans all targets and returns matching files
-func scanTargets(targets []string, filterOpts *filter.Options, cfg *config.Config) ([]scanner.FileInfo, error) {
+// parseTimeFilter parses an age such as "+7d" (more than 7 days ago) or
+// "-1d" (within the last day) into the cutoffs of a time filter
+func parseTimeFilter(s string) (before, after *time.Time, err error) {
+	s = strings.TrimSpace(s)
+	newer := strings.HasPrefix(s, "-")
+	duration, err := utils.ParseDuration(strings.TrimLeft(s, "+-"))
+	if err != nil {
+		return nil, nil, err
+	}
+	cutoff := time.Now().Add(-duration)
+	if newer {
+		return nil, &cutoff, nil
+	}
+	return &cutoff, nil, nil
+}
+
+// scanResults collects what scanning found besides the matching files
+type scanResults struct {
+	errors  []scanner.ScanError // Paths that could not be read
+	skipped []scanner.Skipped   // Directories that were not entered
+	usage   []targetUsage       // Totals of each directory target
+}
+
+// targetUsage is the space used by a directory target
+type targetUsage struct {
+	path string
+	scanner.Usage
+}
+
+// scanTargets scans all targets and returns matching files. The whole plan
+// is kept in memory, as it is summarized, confirmed and journaled before
+// anything is deleted.
+func scanTargets(targets []string, filterOpts *filter.Options, cfg *config.Config) ([]scanner.FileInfo, scanResults, error) {
 	var allFiles []scanner.FileInfo
+	var scan scanResults
 
 	for _, target := range targets {
 		// Expand glob patterns
 		matches, err := filepath.Glob(target)
 		if err != nil {
-			return nil, fmt.Errorf("invalid pattern %s: %w", target, err)
+			return nil, scan, fmt.Errorf("invalid pattern %s: %w", target, err)
 		}
 
 		if len(matches) == 0 {
@@ -285,22 +697,121 @@ func scanTargets(targets []string, filterOpts *filter.Options, cfg *config.Confi
 				continue
 			}
 
-			files, err := scanner.Scan(absPath, recursive, filterOpts)
-			if err != nil {
-				if verbose {
-					fmt.Printf("⚠️  Warning: %v\n", err)
+			if filterOpts.Ignore != nil {
+				// Only the git modes can fail the check
+				if err := filterOpts.Ignore.Check(absPath); err != nil {
+					flag := "--gitignored"
+					if !gitIgnored {
+						flag = "--untracked"
+					}
+					return nil, scan, fmt.Errorf("%s: %w", flag, err)
 				}
-				continue
 			}
 
-			allFiles = append(allFiles, files...)
+			err = scanner.ScanWithCallback(context.Background(), absPath, scanner.Options{
+				Recursive:      recursive,
+				Filter:         filterOpts,
+				Workers:        workers,
+				OneFileSystem:  oneFileSystem,
+				FollowSymlinks: followSymlinks,
+				Protected: func(path string) bool {
+					return !force && cfg.IsProtected(path)
+				},
+				OnSkip: func(s scanner.Skipped) {
+					scan.skipped = append(scan.skipped, s)
+				},
+				OnUsage: func(u scanner.Usage) {
+					scan.usage = append(scan.usage, targetUsage{path: absPath, Usage: u})
+				},
+				OnError: func(e scanner.ScanError) {
+					scan.errors = append(scan.errors, e)
+				},
+			}, func(f scanner.FileInfo) {
+				allFiles = append(allFiles, f)
+			})
+			if err != nil {
+				scan.errors = append(scan.errors, scanner.ScanError{Path: absPath, Err: err})
+			}
+		}
+	}
+
+	return allFiles, scan, nil
+}
+
+// printTargetUsage shows how much each directory target holds in total,
+// which is what removing it entirely frees
+func printTargetUsage(usage []targetUsage) {
+	if len(usage) == 0 {
+		return
+	}
+
+	fmt.Printf("\n📁 Directory targets:\n")
+	for _, u := range usage {
+		fmt.Printf("   %s: %d files, %d directories, %s (%s on disk)\n",
+			u.path, u.Files, u.Dirs, utils.FormatSize(u.Apparent), utils.FormatSize(u.Allocated))
+	}
+}
+
+// printExplanations shows why each planned file matched the filters
+func printExplanations(files []scanner.FileInfo, filterOpts *filter.Options) {
+	fmt.Printf("\n🔎 Why each file matched:\n")
+	for _, f := range files {
+		info, err := os.Lstat(f.Path)
+		if err != nil {
+			fmt.Printf("   %s\n      ↳ %v\n", f.Path, err)
+			continue
+		}
+		fmt.Printf("   %s\n      ↳ %s\n", f.Path, strings.Join(filterOpts.Explain(f.Root, f.Path, info), "; "))
+	}
+}
+
+// printSkippedDirs lists the paths the scan deliberately did not enter.
+// Mount points, other filesystems and protected paths are left in place
+// together with the directories above them; a symlink loop is removed as a
+// plain link.
+func printSkippedDirs(skipped []scanner.Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
+
+	fmt.Printf("\n🗻 %d paths were left out of the scan:\n", len(skipped))
+	for i, s := range skipped {
+		if i == 5 && !verbose {
+			fmt.Printf("   ... and %d more (use -v to list all)\n", len(skipped)-i)
+			break
+		}
+		fmt.Printf("   %s (%s)\n", s.Path, s.Reason)
+	}
+}
+
+// printScanErrors lists the paths that could not be scanned. Neither they
+// nor the directories above them are part of the plan.
+func printScanErrors(scanErrs []scanner.ScanError) {
+	if len(scanErrs) == 0 {
+		return
+	}
+
+	fmt.Printf("\n⚠️  %d paths could not be scanned (they and the directories above them are left in place):\n", len(scanErrs))
+	for i, e := range scanErrs {
+		if i == 5 && !verbose {
+			fmt.Printf("   ... and %d more (use -v to list all)\n", len(scanErrs)-i)
+			break
 		}
+		fmt.Printf("   %v\n", e)
 	}
+}
 
-	return allFiles, nil
+// scanIncomplete returns the error reported when the scan missed paths,
+// so an incomplete run never exits successfully
+func scanIncomplete(scanErrs []scanner.ScanError) error {
+	if len(scanErrs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("scan incomplete: %d paths could not be read", len(scanErrs))
 }
 
-// calculateTotalSize calculates the total size of all files
+// calculateTotalSize calculates the total size of all files, counting
+// every name of a hard-linked file
 func calculateTotalSize(files []scanner.FileInfo) int64 {
 	var total int64
 	for _, f := range files {
@@ -309,12 +820,116 @@ func calculateTotalSize(files []scanner.FileInfo) int64 {
 	return total
 }
 
+// printDryRun lists what would be deleted, marking the files an obstacle
+// would stop
+func printDryRun(files []scanner.FileInfo, obstacles []preflight.Obstacle) {
+	blocked := make(map[string]preflight.Obstacle, len(obstacles))
+	for _, o := range obstacles {
+		blocked[o.File.Path] = o
+	}
+
+	fmt.Println("\n📋 DRY RUN - The following would be deleted:")
+	for _, f := range files {
+		if o, ok := blocked[f.Path]; ok {
+			fmt.Printf("  %s (%s) 🔐 %s\n", f.Path, utils.FormatSize(f.Size), o.Describe())
+		} else {
+			fmt.Printf("  %s (%s)\n", f.Path, utils.FormatSize(f.Size))
+		}
+	}
+	fmt.Println("\n✅ Dry run complete. No files were modified.")
+}
+
+// printObstacles lists planned files that cannot be removed as the
+// current user, with a hint on how to deal with each kind
+func printObstacles(obstacles []preflight.Obstacle) {
+	if len(obstacles) == 0 {
+		return
+	}
+
+	fmt.Printf("\n🔐 %d items cannot be deleted as they are:\n", len(obstacles))
+	var privileged, fixable, attributes bool
+	for i, o := range obstacles {
+		if i < 5 || verbose {
+			fmt.Printf("   %s: %s\n", o.File.Path, o.Describe())
+		} else if i == 5 {
+			fmt.Printf("   ... and %d more (use -v to list all)\n", len(obstacles)-i)
+		}
+		switch {
+		case o.Fixable:
+			fixable = true
+		case o.NeedsPrivilege():
+			privileged = true
+		default:
+			attributes = true
+		}
+	}
+
+	if fixable && !fixPerms {
+		fmt.Println("   Use --fix-perms to make directories you own writable for the run (modes are restored afterwards).")
+	}
+	if (privileged || (fixable && !fixPerms)) && !useSudo {
+		fmt.Println("   Use --sudo to delete items you lack rights for through a privileged helper (sudo nuke --helper).")
+	}
+	if attributes {
+		fmt.Println("   Immutable and append-only items must be cleared first (chattr -i/-a on Linux, chflags on macOS).")
+	}
+}
+
+// helperCommand returns the command line of the privileged helper
+func helperCommand() []string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	return []string{"sudo", exe, "--helper"}
+}
+
+// helperOptions describes the current run to the privileged helper. Files
+// are trashed into the invoking user's trash and owned by that user.
+func helperOptions(trashMgr *trash.Manager) helper.Options {
+	opts := helper.Options{
+		Shred:     shred,
+		Force:     force,
+		UID:       os.Getuid(),
+		GID:       os.Getgid(),
+		Workers:   workers,
+		OnBusy:    retryPolicy.OnBusy,
+		Attempts:  retryPolicy.Attempts,
+		BackoffMS: retryPolicy.Backoff.Milliseconds(),
+	}
+	if trashMgr != nil {
+		opts.TrashDir = trashMgr.BaseDir()
+	}
+	return opts
+}
+
 // displaySummary shows a summary of what will be deleted
 func displaySummary(files []scanner.FileInfo, totalSize int64) {
 	fmt.Printf("\n📊 Summary:\n")
 	fmt.Printf("   Files to delete: %d\n", len(files))
 	fmt.Printf("   Total size: %s\n", utils.FormatSize(totalSize))
 
+	// Hard-linked data is only freed with its last name
+	space := scanner.Account(files)
+	if space.Freed != totalSize {
+		fmt.Printf("   Space freed: %s\n", utils.FormatSize(space.Freed))
+	}
+	// Resumed plans have no allocation recorded
+	if space.Allocated > 0 {
+		fmt.Printf("   On disk: %s\n", utils.FormatSize(space.Allocated))
+	}
+
+	if len(space.Retained) > 0 {
+		fmt.Printf("\n🔗 %d files have hard links outside the targets; deleting them frees no space:\n", len(space.Retained))
+		for i, f := range space.Retained {
+			if i == 5 && !verbose {
+				fmt.Printf("   ... and %d more (use -v to list all)\n", len(space.Retained)-i)
+				break
+			}
+			fmt.Printf("   %s (%s, %d links)\n", f.Path, utils.FormatSize(f.Size), f.Nlink)
+		}
+	}
+
 	if verbose {
 		fmt.Println("\n📁 Files:")
 		for _, f := range files {
@@ -421,7 +1036,7 @@ func countdownWithAbort(seconds int) bool {
 }
 
 // handleInteractiveDelete handles interactive deletion mode
-func handleInteractiveDelete(files []scanner.FileInfo, cfg *config.Config) error {
+func handleInteractiveDelete(files []scanner.FileInfo, cfg *config.Config, obstacles []preflight.Obstacle, verify verifyFunc) error {
 	reader := bufio.NewReader(os.Stdin)
 	deleteAll := false
 
@@ -452,7 +1067,7 @@ func handleInteractiveDelete(files []scanner.FileInfo, cfg *config.Config) error
 				confirm, _ := reader.ReadString('\n')
 				confirm = strings.TrimSpace(strings.ToLower(confirm))
 				if confirm == "y" || confirm == "yes" {
-					return performDeletion(toDelete, cfg)
+					return performDeletion(toDelete, cfg, nil, preflight.Only(obstacles, toDelete), verify)
 				}
 			}
 			fmt.Println("❌ Operation cancelled.")
@@ -465,11 +1080,69 @@ func handleInteractiveDelete(files []scanner.FileInfo, cfg *config.Config) error
 		return nil
 	}
 
-	return performDeletion(toDelete, cfg)
+	return performDeletion(toDelete, cfg, nil, preflight.Only(obstacles, toDelete), verify)
 }
 
-// performDeletion performs the actual deletion operation
-func performDeletion(files []scanner.FileInfo, cfg *config.Config) error {
+// performDeletion records a new run and performs the actual deletion.
+// treeOpts enables tree removal of the planned files; obstacles are those
+// preflight.Check found in files; verify, if set, re-checks the files first.
+func performDeletion(files []scanner.FileInfo, cfg *config.Config, treeOpts *deleter.TreeOptions, obstacles []preflight.Obstacle, verify verifyFunc) error {
+	// Re-check what may have changed while the plan was being confirmed
+	if verify != nil {
+		files = verify(files)
+		obstacles = preflight.Only(obstacles, files)
+		if len(files) == 0 {
+			fmt.Println("✅ Nothing left to delete.")
+			return nil
+		}
+	}
+
+	// The report is created first, so failing to create it leaves no run
+	// behind
+	reportWriter, err := createReport()
+	if err != nil {
+		return err
+	}
+
+	store, err := runs.NewStore()
+	if err != nil {
+		fmt.Printf("⚠️  Could not record run (resume unavailable): %v\n", err)
+		return executeRun(files, cfg, nil, nil, treeOpts, obstacles, reportWriter)
+	}
+	pruneRuns(store, cfg)
+
+	run, err := store.Create(files, shred, hardDelete)
+	if err != nil {
+		fmt.Printf("⚠️  Could not record run (resume unavailable): %v\n", err)
+		return executeRun(files, cfg, nil, nil, treeOpts, obstacles, reportWriter)
+	}
+
+	return executeRun(files, cfg, store, run, treeOpts, obstacles, reportWriter)
+}
+
+// pruneRuns removes recorded runs older than the trash retention period,
+// as what they trashed expires along with them
+func pruneRuns(store *runs.Store, cfg *config.Config) {
+	removed, err := store.Prune(time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour)
+	if err != nil {
+		fmt.Printf("⚠️  Could not remove old runs: %v\n", err)
+	} else if removed > 0 {
+		fmt.Printf("🧹 Removed %d runs older than %d days\n", removed, cfg.TrashRetentionDays)
+	}
+}
+
+// createReport opens the --report file, if one was requested
+func createReport() (*report.Writer, error) {
+	if reportPath == "" {
+		return nil, nil
+	}
+	return report.Create(reportPath)
+}
+
+// executeRun deletes the files, journaling each completed file to the run
+// so an interrupted or partially failed run can be resumed, and writing
+// each result to reportWriter if set
+func executeRun(files []scanner.FileInfo, cfg *config.Config, store *runs.Store, run *runs.Run, treeOpts *deleter.TreeOptions, obstacles []preflight.Obstacle, reportWriter *report.Writer) error {
 	fmt.Printf("\n🗑️  Deleting %d files...\n", len(files))
 
 	// Create progress bar
@@ -489,58 +1162,634 @@ func performDeletion(files []scanner.FileInfo, cfg *config.Config) error {
 
 	// Create trash manager (skip if NUKE_NO_TRASH=1 is set)
 	var trashMgr *trash.Manager
-	if os.Getenv("NUKE_NO_TRASH") != "1" {
+	if !hardDelete {
 		var err error
 		trashMgr, err = trash.NewManager()
 		if err != nil {
+			if reportWriter != nil {
+				_ = reportWriter.Close()
+			}
+			if run != nil {
+				_ = run.Close()
+			}
 			return fmt.Errorf("failed to initialize trash: %w", err)
 		}
 	}
 
 	// Create deleter
 	del := deleter.New(workers, shred, trashMgr)
+	del.SetTreeOptions(treeOpts)
+	del.SetRetryPolicy(retryPolicy)
 
-	// Track errors
-	var errMu sync.Mutex
-	var errors []error
+	// Stop dispatching new work on SIGINT/SIGTERM; in-flight operations
+	// finish so nothing is left half-trashed or half-shredded
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// Restore default signal handling so a second Ctrl+C force quits
+		stop()
+	}()
+
+	summary := report.NewSummary()
+	var mu sync.Mutex
+	interruptNoticed := false
 
 	// Progress callback
-	onProgress := func(path string, err error) {
+	onProgress := func(res deleter.Result) {
 		//nolint:errcheck // Progress bar errors are non-critical
 		bar.Add(1)
-		if err != nil {
-			errMu.Lock()
-			errors = append(errors, fmt.Errorf("%s: %w", path, err))
-			errMu.Unlock()
+		summary.Add(res)
+		if reportWriter != nil {
+			if err := reportWriter.Write(res); err != nil && verbose {
+				fmt.Printf("\n⚠️  Could not write report entry for %s: %v\n", res.Path, err)
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil && !interruptNoticed {
+			interruptNoticed = true
+			fmt.Printf("\n⚠️  Interrupt received - finishing in-flight operations (Ctrl+C again to force quit)...\n")
+		}
+		switch {
+		case res.Action == deleter.ActionFailed:
+			if verbose {
+				fmt.Printf("\n⚠️  Error: %s: %v\n", res.Path, res.Err)
+			}
+		case res.Action == deleter.ActionSkipped:
 			if verbose {
-				fmt.Printf("\n⚠️  Error: %s: %v\n", path, err)
+				fmt.Printf("\n⏭️  Skipped: %s: %v\n", res.Path, res.Err)
+			}
+		case run != nil:
+			if jerr := run.MarkDone(res.Path); jerr != nil && verbose {
+				fmt.Printf("\n⚠️  Could not journal %s: %v\n", res.Path, jerr)
+			}
+		}
+	}
+
+	// Temporarily make read-only directories the user owns writable
+	if fixPerms {
+		fixes, err := preflight.Fix(obstacles)
+		if err != nil {
+			fmt.Printf("⚠️  Could not fix permissions: %v\n", err)
+		}
+		obstacles = fixes.Remaining(obstacles)
+		if len(fixes) > 0 {
+			fmt.Printf("🔧 Made %d directories writable for this run\n", len(fixes))
+			defer func() {
+				if err := fixes.Restore(); err != nil {
+					fmt.Printf("⚠️  Could not restore directory permissions: %v\n", err)
+				}
+			}()
+		}
+	}
+
+	// Items the current user cannot remove go to the privileged helper
+	// first, so their directories are handled before anything above them
+	var pending []scanner.FileInfo
+	if useSudo {
+		var elevated []scanner.FileInfo
+		elevated, files = preflight.Partition(files, obstacles)
+		if len(elevated) > 0 {
+			fmt.Printf("\n🔐 Deleting %d items with elevated rights (sudo)...\n", len(elevated))
+			helperPending, err := helper.Delete(helperCommand(), helperOptions(trashMgr), elevated, onProgress)
+			if err != nil {
+				fmt.Printf("\n⚠️  %v\n", err)
 			}
+			pending = append(pending, helperPending...)
+		}
+		if len(pending) > 0 {
+			// Tree removal would sweep up what the helper left behind
+			del.SetTreeOptions(nil)
 		}
 	}
 
 	// Perform deletion
-	del.Delete(files, onProgress)
+	pending = append(pending, del.Delete(ctx, files, onProgress)...)
+	interrupted := ctx.Err() != nil
 
 	fmt.Println()
 
 	// Report results
-	successCount := len(files) - len(errors)
-	fmt.Printf("\n✅ Successfully processed: %d files\n", successCount)
+	successCount := summary.Succeeded()
+	failedCount := summary.Count(deleter.ActionFailed)
+	if interrupted {
+		fmt.Printf("\n🛑 Deletion interrupted.\n")
+	}
+	fmt.Printf("\n✅ Successfully processed: %d files (%s)\n", successCount, utils.FormatSize(summary.Bytes()))
 
-	if len(errors) > 0 {
-		fmt.Printf("⚠️  Errors: %d\n", len(errors))
-		if verbose {
-			for _, e := range errors {
-				fmt.Printf("   - %v\n", e)
-			}
+	if skipped := summary.Count(deleter.ActionSkipped); skipped > 0 {
+		fmt.Printf("⏭️  Skipped: %d files\n", skipped)
+	}
+
+	if retried, recovered := summary.Retried(); retried > 0 {
+		fmt.Printf("🔁 Retried: %d files (%d succeeded on retry)\n", retried, recovered)
+	}
+
+	if failedCount > 0 {
+		fmt.Printf("⚠️  Errors: %d\n", failedCount)
+		printErrorGroups(summary.ErrorGroups())
+	}
+
+	if len(pending) > 0 {
+		fmt.Printf("⏸️  Not attempted: %d files\n", len(pending))
+	}
+
+	if reportWriter != nil {
+		if err := reportWriter.Close(); err != nil {
+			fmt.Printf("⚠️  Could not write report: %v\n", err)
+		} else {
+			fmt.Printf("📝 Report written to: %s\n", reportPath)
+		}
+	}
+
+	if run != nil {
+		if err := run.Close(); err != nil {
+			fmt.Printf("⚠️  Could not save run journal: %v\n", err)
+		}
+		if !interrupted && failedCount == 0 {
+			_ = store.Delete(run.ID)
+		} else {
+			fmt.Printf("\n▶️  Resume with: nuke resume %s\n", run.ID)
 		}
 	}
 
-	if !shred && os.Getenv("NUKE_NO_TRASH") != "1" {
+	if successCount > 0 && !shred && !hardDelete {
 		fmt.Println("\n💡 Files moved to trash. Use --empty-trash to permanently delete.")
 		fmt.Printf("   Use --restore=<filename> to restore a file.\n")
 	}
 
+	if interrupted {
+		return fmt.Errorf("deletion interrupted")
+	}
+
+	return nil
+}
+
+// printErrorGroups prints errors grouped by class and directory. Only the
+// directories with the most errors are listed unless verbose is set.
+func printErrorGroups(groups []report.ErrorGroup) {
+	const maxDirs = 5
+
+	for _, group := range groups {
+		fmt.Printf("   %s: %d\n", group.Class, group.Count)
+		for i, dir := range group.Dirs {
+			if i == maxDirs && !verbose {
+				fmt.Printf("      ... and %d more directories (use -v to list all)\n", len(group.Dirs)-maxDirs)
+				break
+			}
+			fmt.Printf("      %s (%d)\n", dir.Dir, dir.Count)
+		}
+	}
+}
+
+// handleResume continues an interrupted run, acting only on files that are
+// unchanged since the run was planned
+func handleResume(runIDs []string, cfg *config.Config) error {
+	store, err := runs.NewStore()
+	if err != nil {
+		return err
+	}
+	pruneRuns(store, cfg)
+
+	if len(runIDs) == 0 {
+		return handleListRuns(store)
+	}
+
+	runID := runIDs[0]
+	hdr, remaining, err := store.Remaining(runID)
+	if err != nil {
+		return err
+	}
+
+	// Re-validate every remaining path so nothing that changed since
+	// planning is ever deleted
+	var candidates []scanner.FileInfo
+	var gone, changed int
+	keep := make(map[string]bool) // Directories that must stay because they hold skipped files
+	for _, f := range remaining {
+		switch err := runs.Validate(f); {
+		case err == nil:
+			candidates = append(candidates, f)
+		case errors.Is(err, runs.ErrGone):
+			gone++
+		default:
+			changed++
+			fmt.Printf("⚠️  Skipping %s: %v\n", f.Path, err)
+			for dir := filepath.Dir(f.Path); !keep[dir] && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+				keep[dir] = true
+			}
+		}
+	}
+
+	var files []scanner.FileInfo
+	for _, f := range candidates {
+		if f.IsDir && keep[f.Path] {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	fmt.Printf("▶️  Resuming run %s (%d of %d files remaining)\n", hdr.ID, len(remaining), hdr.Total)
+	if gone > 0 {
+		fmt.Printf("   Already gone: %d files\n", gone)
+	}
+	if changed > 0 {
+		fmt.Printf("   Changed since planning (skipped): %d files\n", changed)
+	}
+
+	if len(files) == 0 {
+		fmt.Println("✅ Nothing left to delete.")
+		return store.Delete(runID)
+	}
+
+	// Continue with the same deletion mode as the original run
+	shred = hdr.Shred
+	hardDelete = hdr.NoTrash
+
+	displaySummary(files, calculateTotalSize(files))
+	obstacles := preflight.Check(files)
+	printObstacles(obstacles)
+
+	if dryRun {
+		printDryRun(files, obstacles)
+		return nil
+	}
+
+	if !force {
+		if !confirmDeletion(len(files)) {
+			fmt.Println("❌ Operation cancelled.")
+			return nil
+		}
+	}
+
+	reportWriter, err := createReport()
+	if err != nil {
+		return err
+	}
+	run, err := store.Open(runID)
+	if err != nil {
+		if reportWriter != nil {
+			_ = reportWriter.Close()
+		}
+		return err
+	}
+
+	// Resumed files were validated one by one, so never re-walk their trees
+	return executeRun(files, cfg, store, run, nil, obstacles, reportWriter)
+}
+
+// handleDupes finds files with identical content beneath the targets and
+// deletes all but one of each group, chosen by the keep policy
+func handleDupes(targets []string, cfg *config.Config) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no directories given (usage: nuke dupes [OPTIONS] <dirs>...)")
+	}
+	policy, err := dupes.ParsePolicy(keepPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid --keep value: %w", err)
+	}
+	keeper := dupes.Keeper{Policy: policy}
+	for _, dir := range preferDirs {
+		// Shells do not expand "~" after "="
+		if strings.HasPrefix(dir, "~/") {
+			if homeDir, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(homeDir, dir[2:])
+			}
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid --prefer value: %w", err)
+		}
+		keeper.Priority = append(keeper.Priority, absDir)
+	}
+
+	filterOpts, err := createFilterOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid filter options: %w", err)
+	}
+
+	// Duplicates are looked for in whole trees
+	recursive = true
+	fmt.Println("🔍 Scanning targets...")
+	files, scan, err := scanTargets(targets, filterOpts, cfg)
+	if err != nil {
+		return fmt.Errorf("scan error: %w", err)
+	}
+
+	fmt.Println("🧮 Comparing file contents...")
+	groups := dupes.Find(files, workers, func(e dupes.HashError) {
+		scan.errors = append(scan.errors, scanner.ScanError{Path: e.Path, Err: e.Err})
+	})
+	if len(groups) == 0 {
+		printSkippedDirs(scan.skipped)
+		printScanErrors(scan.errors)
+		fmt.Println("✅ No duplicate files found.")
+		return scanIncomplete(scan.errors)
+	}
+
+	var remove []scanner.FileInfo
+	var reclaimable int64
+	kept := make(map[string]scanner.FileInfo)
+	fmt.Printf("\n👯 %d groups of duplicate files:\n", len(groups))
+	for _, g := range groups {
+		keep, rest := keeper.Split(g)
+		for _, f := range rest {
+			kept[f.Path] = keep
+		}
+		fmt.Printf("\n   %d × %s (%s reclaimable)\n", len(g.Files), utils.FormatSize(g.Size), utils.FormatSize(g.Reclaimable()))
+		fmt.Printf("   ✔ keep   %s\n", keep.Path)
+		for _, f := range rest {
+			fmt.Printf("   ✘ remove %s\n", f.Path)
+		}
+		remove = append(remove, rest...)
+		reclaimable += g.Reclaimable()
+	}
+
+	displaySummary(remove, reclaimable)
+	obstacles := preflight.Check(remove)
+	printObstacles(obstacles)
+	printSkippedDirs(scan.skipped)
+	printScanErrors(scan.errors)
+
+	if dryRun {
+		fmt.Println("\n✅ Dry run complete. No files were modified.")
+		return scanIncomplete(scan.errors)
+	}
+
+	// The contents were compared before the confirmation; a duplicate is
+	// only removed if it and the copy kept in its place are still as they
+	// were, so changing or removing the kept copy never loses the content
+	verify := func(files []scanner.FileInfo) []scanner.FileInfo {
+		var unchanged []scanner.FileInfo
+		for _, f := range files {
+			keep := kept[f.Path]
+			if !dupes.Unchanged(keep, f) {
+				fmt.Printf("⚠️  Keeping %s: it or %s changed since they were compared\n", f.Path, keep.Path)
+				continue
+			}
+			unchanged = append(unchanged, f)
+		}
+		return unchanged
+	}
+
+	return confirmAndDelete(remove, cfg, nil, obstacles, verify, scan.errors)
+}
+
+// handleProjects lists the projects beneath the targets with the space
+// their rebuildable artifacts take. With --inactive it keeps only the
+// projects without activity for that long and offers to delete their
+// artifacts.
+func handleProjects(targets []string, cfg *config.Config) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no directories given (usage: nuke projects [OPTIONS] <dirs>...)")
+	}
+	var cutoff time.Time
+	if inactiveFor != "" {
+		duration, err := utils.ParseDuration(inactiveFor)
+		if err != nil {
+			return fmt.Errorf("invalid --inactive value: %w", err)
+		}
+		cutoff = time.Now().Add(-duration)
+	}
+	if err := projects.Sort(nil, sortBy); err != nil {
+		return fmt.Errorf("invalid --sort value: %w", err)
+	}
+
+	// All known ecosystems unless --profile narrows them down
+	var profiles []filter.Profile
+	for _, p := range cfg.Profiles {
+		profiles = append(profiles, filter.Profile{Name: p.Name, Manifests: p.Manifests, Artifacts: p.Artifacts})
+	}
+	if profileNames == "" {
+		seen := make(map[string]bool)
+		for _, p := range profiles {
+			seen[p.Name] = true
+		}
+		for _, p := range filter.BuiltinProfiles {
+			if !seen[p.Name] {
+				profiles = append(profiles, p)
+			}
+		}
+	} else {
+		var err error
+		if profiles, err = filter.LookupProfiles(profileNames, profiles); err != nil {
+			return fmt.Errorf("invalid --profile value: %w", err)
+		}
+	}
+
+	fmt.Println("🔍 Looking for projects...")
+	var scanErrs []scanner.ScanError
+	found := projects.Find(context.Background(), targets, projects.Options{
+		Profiles: profiles,
+		Workers:  workers,
+		OnError: func(e scanner.ScanError) {
+			scanErrs = append(scanErrs, e)
+		},
+	})
+	_ = projects.Sort(found, sortBy)
+
+	var listed []projects.Project
+	for _, p := range found {
+		if inactiveFor == "" || p.LastActivity.Before(cutoff) {
+			listed = append(listed, p)
+		}
+	}
+	if len(listed) == 0 {
+		printScanErrors(scanErrs)
+		if inactiveFor != "" {
+			fmt.Printf("✅ No projects inactive for %s found (%d projects checked).\n", inactiveFor, len(found))
+		} else {
+			fmt.Println("✅ No projects found.")
+		}
+		return scanIncomplete(scanErrs)
+	}
+
+	printProjects(listed)
+	printScanErrors(scanErrs)
+
+	// Without --inactive this is only a report
+	if inactiveFor == "" {
+		fmt.Println("\n💡 Use --inactive=<duration> to clean up the artifacts of inactive projects.")
+		return scanIncomplete(scanErrs)
+	}
+
+	var artifacts []string
+	for _, p := range listed {
+		for _, a := range p.Artifacts {
+			artifacts = append(artifacts, a.Path)
+		}
+	}
+	if len(artifacts) == 0 {
+		fmt.Println("\n✅ The inactive projects have no artifacts to clean up.")
+		return scanIncomplete(scanErrs)
+	}
+
+	filterOpts, err := createFilterOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid filter options: %w", err)
+	}
+	// Artifacts were selected by name, which lifts a default protection
+	// such as node_modules
+	for _, name := range filter.ArtifactNames(profiles) {
+		cfg.UnprotectDefault(name)
+	}
+
+	recursive = true
+	files, scan, err := scanTargets(artifacts, filterOpts, cfg)
+	if err != nil {
+		return fmt.Errorf("scan error: %w", err)
+	}
+	scan.errors = append(scanErrs, scan.errors...)
+	if len(files) == 0 {
+		printScanErrors(scan.errors)
+		fmt.Println("✅ No files match the specified criteria.")
+		return scanIncomplete(scan.errors)
+	}
+
+	displaySummary(files, calculateTotalSize(files))
+	obstacles := preflight.Check(files)
+	printObstacles(obstacles)
+	printSkippedDirs(scan.skipped)
+
+	if dryRun {
+		fmt.Println("\n✅ Dry run complete. No files were modified.")
+		return scanIncomplete(scan.errors)
+	}
+
+	return confirmAndDelete(files, cfg, treeOptions(filterOpts, cfg, scan.skipped), obstacles, nil, scan.errors)
+}
+
+// printProjects shows projects as a table, with the space their artifacts
+// take and how long they have been inactive
+func printProjects(list []projects.Project) {
+	var total int64
+	fmt.Printf("\n📦 %d projects:\n\n", len(list))
+	fmt.Printf("   %12s  %9s  %-16s  %s\n", "RECLAIMABLE", "INACTIVE", "KIND", "PROJECT")
+	for _, p := range list {
+		inactive := "-"
+		if !p.LastActivity.IsZero() {
+			inactive = fmt.Sprintf("%dd", int(time.Since(p.LastActivity).Hours()/24))
+		}
+		reclaimable := p.Reclaimable().Allocated
+		total += reclaimable
+		fmt.Printf("   %12s  %9s  %-16s  %s\n", utils.FormatSize(reclaimable), inactive, strings.Join(p.Kinds, ","), p.Path)
+		if verbose {
+			for _, a := range p.Artifacts {
+				fmt.Printf("   %12s  %9s  %-16s    %s\n", utils.FormatSize(a.Usage.Allocated), "", a.Profile, a.Path)
+			}
+			if p.ActiveFile != "" {
+				fmt.Printf("   %12s  %9s  %-16s    last changed: %s\n", "", "", "", p.ActiveFile)
+			}
+		}
+	}
+	fmt.Printf("\n   Total reclaimable: %s\n", utils.FormatSize(total))
+}
+
+// handleTop shows the largest files and directories beneath the targets
+// and deletes the ones the user picks by number
+func handleTop(targets []string, cfg *config.Config) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no directories given (usage: nuke top [OPTIONS] <dirs>...)")
+	}
+	filterOpts, err := createFilterOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid filter options: %w", err)
+	}
+
+	recursive = true
+	fmt.Println("🔍 Scanning targets...")
+	files, scan, err := scanTargets(targets, filterOpts, cfg)
+	if err != nil {
+		return fmt.Errorf("scan error: %w", err)
+	}
+	entries := top.Largest(files, topLimit)
+	printSkippedDirs(scan.skipped)
+	printScanErrors(scan.errors)
+	if len(entries) == 0 {
+		fmt.Println("✅ No files match the specified criteria.")
+		return scanIncomplete(scan.errors)
+	}
+
+	fmt.Printf("\n📏 Largest %d entries:\n\n", len(entries))
+	for i, e := range entries {
+		path := e.Path
+		detail := ""
+		if e.IsDir {
+			path += string(filepath.Separator)
+			detail = fmt.Sprintf("  (%d files)", e.Usage.Files)
+		}
+		fmt.Printf("   %3d. %10s  %s%s\n", i+1, utils.FormatSize(e.Usage.Allocated), path, detail)
+	}
+
+	fmt.Print("\n❓ Select entries to delete (e.g. 1 3-5, empty to cancel): ")
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		fmt.Println("❌ Operation cancelled.")
+		return nil
+	}
+	indexes, err := top.ParseSelection(input, len(entries))
+	if err != nil {
+		return err
+	}
+	var selected []string
+	for _, i := range indexes {
+		selected = append(selected, entries[i].Path)
+	}
+
+	// The selection is deleted as if it had been given to nuke -r, with
+	// the same filters
+	fmt.Println("🔍 Scanning selection...")
+	files, scan, err = scanTargets(top.Outermost(selected), filterOpts, cfg)
+	if err != nil {
+		return fmt.Errorf("scan error: %w", err)
+	}
+	if len(files) == 0 {
+		printScanErrors(scan.errors)
+		fmt.Println("✅ No files match the specified criteria.")
+		return scanIncomplete(scan.errors)
+	}
+
+	displaySummary(files, calculateTotalSize(files))
+	obstacles := preflight.Check(files)
+	printObstacles(obstacles)
+	printSkippedDirs(scan.skipped)
+	printScanErrors(scan.errors)
+
+	if dryRun {
+		fmt.Println("\n✅ Dry run complete. No files were modified.")
+		return scanIncomplete(scan.errors)
+	}
+
+	return confirmAndDelete(files, cfg, treeOptions(filterOpts, cfg, scan.skipped), obstacles, nil, scan.errors)
+}
+
+// handleListRuns lists runs that can be resumed
+func handleListRuns(store *runs.Store) error {
+	headers, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(headers) == 0 {
+		fmt.Println("✅ No interrupted runs to resume.")
+		return nil
+	}
+
+	fmt.Printf("▶️  Resumable runs:\n\n")
+	for _, hdr := range headers {
+		mode := "trash"
+		if hdr.Shred {
+			mode = "shred"
+		} else if hdr.NoTrash {
+			mode = "permanent"
+		}
+		fmt.Printf("   %s  %s  %d files (%s)\n", hdr.ID, hdr.CreatedAt.Format("2006-01-02 15:04:05"), hdr.Total, mode)
+	}
+	fmt.Println("\n   Use 'nuke resume <run-id>' to continue a run.")
+
 	return nil
 }
 
@@ -662,6 +1911,10 @@ nuke - A safer, faster, and more user-friendly alternative to rm
 
 USAGE:
     nuke [OPTIONS] <targets>...
+    nuke resume [run-id]
+    nuke dupes [OPTIONS] <dirs>...
+    nuke projects [OPTIONS] <dirs>...
+    nuke top [OPTIONS] <dirs>...
 
 DESCRIPTION:
     nuke is a command-line utility for deleting files safely. It provides
@@ -672,11 +1925,23 @@ OPTIONS:
     -h, --help           Show this help message
     -r, --recursive      Delete directories recursively
     -f, --force          Skip confirmation prompts and protected path checks
-    -i, --interactive    Ask for confirmation for each file
+    -i, --interactive    Review the plan in a full-screen tree: space
+                         toggles, / filters, d continues, q cancels. Asks
+                         for each file when not on a terminal
     -v, --verbose        Show detailed output
     --dry-run            Show what would be deleted without actually deleting
     --shred              Securely overwrite files before deletion
     --no-countdown       Skip the countdown timer
+    --sudo               Delete items the current user cannot remove through
+                         a privileged helper (sudo nuke --helper)
+    --fix-perms          Make read-only parent directories you own writable
+                         during the run, restoring their modes afterwards
+    --strict             Abort before deleting anything if part of the scan
+                         failed (unreadable directories, missing targets)
+    --one-file-system    Stay on the filesystem of each target; directories
+                         on other filesystems are left in place
+    --follow-symlinks    Descend into symlinked directories (loops are
+                         detected and skipped)
 
 TRASH OPERATIONS:
     --empty-trash        Permanently delete all files in trash
@@ -684,17 +1949,111 @@ TRASH OPERATIONS:
     --show-trash         Show what's in the trash
     --restore=<file>     Restore a file from trash
 
+RESUMING RUNS:
+    nuke resume          List interrupted runs that can be resumed
+    nuke resume <id>     Continue a run, skipping files that changed since
+                         it was planned
+
+CLEANUP PROFILES:
+    --profile=<names>    Delete the build and cache artifacts of the named
+                         ecosystems (comma separated), only where the
+                         project's manifest sits next to them. Implies -r.
+                         node       node_modules, .next, dist, coverage, ...
+                                    next to package.json
+                         python     __pycache__, .pytest_cache, .mypy_cache,
+                                    .tox, *.egg-info, ... next to *.py,
+                                    pyproject.toml, setup.py, ...
+                         rust       target next to Cargo.toml
+                         gradle     .gradle, build next to build.gradle
+                         maven      target next to pom.xml
+                         xcode      DerivedData, build next to *.xcodeproj
+                         swift      .build, .swiftpm next to Package.swift
+                         dotnet     bin, obj next to *.csproj, *.sln
+                         terraform  .terraform next to *.tf
+                         elixir     _build, deps, cover next to mix.exs
+                         More can be defined under profiles: in config.yaml
+
+LARGEST ENTRIES:
+    nuke top <dirs>      List the largest files and directories beneath the
+                         directories (totalled like du), then delete the
+                         entries picked by number or range, e.g. 1 3-5.
+                         Filters apply to what is counted and deleted.
+    --limit=<n>          Number of entries listed (default: 20)
+
+STALE PROJECTS:
+    nuke projects <dirs> List the projects beneath the directories (found
+                         by .git, .hg, .svn or a profile's manifest) with
+                         the space their build artifacts take
+    --inactive=<dur>     Only projects without a commit or source change
+                         for this long, and offer to delete their artifacts
+    --sort=<key>         Order by size (default), age or name
+    --profile=<names>    Only look for these ecosystems' artifacts
+
+DUPLICATE FILES:
+    nuke dupes <dirs>    Find files with identical content (compared by
+                         size, then a partial hash, then a full hash) and
+                         delete all but one of each group. Filters apply.
+    --keep=<policy>      Which file of a group to keep: oldest (default),
+                         newest or shortest (path)
+    --prefer=<dir>       Keep files under this directory first; repeat to
+                         give a priority list
+
 FILTERING OPTIONS:
     --older-than=<dur>   Delete files older than duration (e.g., 30d, 24h)
     --newer-than=<dur>   Delete files newer than duration
     --size=<size>        Filter by size (+100M for >100MB, -1G for <1GB)
-    --exclude=<pattern>  Exclude files matching glob pattern
-    --include=<pattern>  Include only files matching glob pattern
+    --exclude=<pattern>  Exclude paths matching a gitignore-style pattern
+                         (**, !negation, /anchored to the target, dir/)
+    --include=<pattern>  Include only paths matching a gitignore-style pattern
     --regex=<pattern>    Match files using regex pattern
+    --type=<types>       Match entry types, comma separated: file, dir,
+                         symlink, broken-symlink, socket, fifo, device
+    --owner=<user>       Match files owned by a user (name or UID)
+    --group=<group>      Match files owned by a group (name or GID)
+    --perm=<mode>        Match permission bits: 644 exactly, -022 with all
+                         bits set, /111 with any bit set
+    --empty              Match empty files and empty directories
+    --atime=<age>        Match by last access (+7d more than 7 days ago,
+                         -1d within the last day)
+    --ctime=<age>        Match by last inode change, like --atime
+    --min-depth=<n>      Match only entries at least n levels below a target
+    --max-depth=<n>      Do not match or descend more than n levels below
+                         a target
+    --mime=<types>       Match files by content type detected from magic
+                         numbers, e.g. image/*, application/x-coredump,
+                         x-*executable (comma separated)
+    --contains=<regex>   Match files whose content matches a regex
+    --contains-max-size=<size>
+                         Skip larger files in --contains (default: 16M)
+    --gitignored         Select only paths git ignores (.gitignore files,
+                         .git/info/exclude and core.excludesFile); tracked
+                         files are never selected
+    --untracked          Select everything git does not track, ignored or
+                         not (like git clean -x)
+    --respect-ignore-files
+                         Leave paths listed in .nukeignore files alone
+    --where=<expr>       Select files with a boolean expression, e.g.
+                         'ext in [log,tmp] and (mtime > 7d or size > 1G)
+                         and not path ~ "archive/"'
+                         Fields: name, path, ext, type, mime, owner,
+                         group (=, !=, ~, !~, in), size, mtime, atime, ctime,
+                         depth (=, !=, <, <=, >, >=; times are ages)
+    --explain            Show why each file matched the filters
+
+REPORTING OPTIONS:
+    --report=<file>      Write a per-file report of what was done
+                         (CSV if the file ends in .csv, JSON otherwise)
 
 PERFORMANCE OPTIONS:
     --workers=<n>        Number of concurrent workers (default: 8)
 
+RETRY OPTIONS:
+    --on-busy=<policy>   What to do with busy files (EBUSY, ETXTBSY, NFS
+                         silly-renames): retry (default), skip or fail
+    --retries=<n>        Retries per busy file (default: 2)
+    --retry-backoff=<d>  Delay before the first retry, doubled after each
+                         (default: 100ms)
+
 EXAMPLES:
     nuke file.txt                    Delete a single file
     nuke -r directory/               Delete directory recursively
@@ -703,6 +2062,13 @@ EXAMPLES:
     nuke --size=+100M downloads/     Delete files larger than 100MB
     nuke --exclude=*.cfg config/     Delete all except .cfg files
     nuke --shred secret.txt          Securely delete sensitive file
+    nuke --profile=node,python ~/code
+                                     Remove node_modules, __pycache__, ...
+    nuke top ~/Downloads             Pick the largest entries to delete
+    nuke projects --inactive=90d ~/code
+                                     Clean artifacts of projects idle 90 days
+    nuke dupes --prefer=~/Photos ~/Downloads ~/Photos
+                                     Remove duplicates, keeping ~/Photos
     nuke --show-trash                Show what's in the trash
     nuke --cleanup-trash             Auto-cleanup old trash files
     nuke --empty-trash               Empty the trash permanently
@@ -713,10 +2079,14 @@ SAFETY FEATURES:
     - Dangerous pattern detection: Warns about risky operations like '/*'
     - Confirmation required: Asks before deleting
     - Countdown timer: 5-second countdown for large operations (Ctrl+C to abort)
+    - Graceful abort: Ctrl+C during deletion finishes in-flight files and
+      records the run so it can be continued with 'nuke resume'
     - Soft delete: Files are moved to trash by default (use --shred to bypass)
 
 ENVIRONMENT VARIABLES:
-    NUKE_NO_TRASH=1      Permanently delete files instead of moving to trash
+    NUKE_NO_TRASH=1      Permanently delete files instead of moving to trash.
+                         Recursive targets are removed with a fast native
+                         walker that still honors filters and protected paths
 
 PROTECTED PATHS:
     The following paths are protected by default:
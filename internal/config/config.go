@@ -17,6 +17,35 @@ type Config struct {
 	TrashMaxSizeMB int
 	// AutoCleanupEnabled enables automatic trash cleanup (default: true)
 	AutoCleanupEnabled bool
+	// Exclude lists gitignore-style patterns excluded from every scan
+	Exclude []string
+	// Profiles lists user-defined cleanup profiles
+	Profiles []Profile
+
+	custom map[string]bool // Protected paths added by the user rather than by default
+}
+
+// Profile is a user-defined cleanup profile: artifacts that are only
+// removed next to one of the manifests
+type Profile struct {
+	Name      string
+	Manifests []string
+	Artifacts []string
+
+	current string // List being read
+}
+
+// add appends a value to the list being read
+func (p *Profile) add(value string) {
+	if value == "" {
+		return
+	}
+	switch p.current {
+	case "manifests":
+		p.Manifests = append(p.Manifests, value)
+	case "artifacts":
+		p.Artifacts = append(p.Artifacts, value)
+	}
 }
 
 // DefaultProtectedPaths returns the default list of protected paths
@@ -105,39 +134,87 @@ func (c *Config) loadUserConfig(path string) {
 		return
 	}
 
-	// Simple parsing - look for protected_paths section
+	// Simple parsing - look for protected_paths and exclude sections
 	lines := strings.Split(string(data), "\n")
-	inProtectedPaths := false
+	section := ""
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
 
-		if line == "protected_paths:" {
-			inProtectedPaths = true
+		if line == "protected_paths:" || line == "exclude:" || line == "profiles:" {
+			section = strings.TrimSuffix(line, ":")
 			continue
 		}
 
-		if inProtectedPaths {
+		if section == "profiles" {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if raw[0] != ' ' && raw[0] != '\t' {
+				// End of the section
+				section = ""
+			} else {
+				c.parseProfileLine(line)
+				continue
+			}
+		}
+
+		if section != "" {
 			if strings.HasPrefix(line, "- ") {
-				path := strings.TrimPrefix(line, "- ")
-				path = strings.Trim(path, "\"'")
-				if path != "" {
-					// Expand home directory
-					if strings.HasPrefix(path, "~/") {
-						if homeDir, err := os.UserHomeDir(); err == nil {
-							path = filepath.Join(homeDir, path[2:])
-						}
-					}
-					c.ProtectedPaths = append(c.ProtectedPaths, path)
+				value := strings.TrimPrefix(line, "- ")
+				value = strings.Trim(value, "\"'")
+				if value == "" {
+					continue
+				}
+				switch section {
+				case "protected_paths":
+					c.AddProtectedPath(value)
+				case "exclude":
+					c.Exclude = append(c.Exclude, value)
 				}
 			} else if !strings.HasPrefix(line, "#") && line != "" {
-				// End of protected_paths section
-				inProtectedPaths = false
+				// End of the section
+				section = ""
 			}
 		}
 	}
 }
 
+// parseProfileLine reads one line of the profiles section:
+//
+//	profiles:
+//	  unity:
+//	    manifests: [ProjectSettings]
+//	    artifacts:
+//	      - Library/
+//	      - Temp/
+func (c *Config) parseProfileLine(line string) {
+	if value, ok := strings.CutPrefix(line, "- "); ok {
+		if n := len(c.Profiles); n > 0 {
+			c.Profiles[n-1].add(strings.Trim(strings.TrimSpace(value), "\"'"))
+		}
+		return
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	n := len(c.Profiles)
+	if n == 0 || (key != "manifests" && key != "artifacts") {
+		c.Profiles = append(c.Profiles, Profile{Name: key})
+		return
+	}
+
+	p := &c.Profiles[n-1]
+	p.current = key
+	// Inline lists: artifacts: [Library/, Temp/]
+	for _, item := range strings.Split(strings.Trim(value, "[]"), ",") {
+		p.add(strings.Trim(strings.TrimSpace(item), "\"'"))
+	}
+}
+
 // IsProtected checks if a path is protected
 func (c *Config) IsProtected(path string) bool {
 	// Normalize the path
@@ -188,6 +265,22 @@ func (c *Config) IsProtected(path string) bool {
 	return false
 }
 
+// UnprotectDefault lifts a default protection of a name that applies at
+// any depth, such as node_modules, so it can be cleaned up. The same name
+// added to protected_paths by the user stays protected.
+func (c *Config) UnprotectDefault(name string) {
+	if c.custom[name] {
+		return
+	}
+	kept := c.ProtectedPaths[:0]
+	for _, p := range c.ProtectedPaths {
+		if p != name {
+			kept = append(kept, p)
+		}
+	}
+	c.ProtectedPaths = kept
+}
+
 // AddProtectedPath adds a new protected path
 func (c *Config) AddProtectedPath(path string) {
 	// Expand home directory
@@ -196,5 +289,9 @@ func (c *Config) AddProtectedPath(path string) {
 			path = filepath.Join(homeDir, path[2:])
 		}
 	}
+	if c.custom == nil {
+		c.custom = make(map[string]bool)
+	}
+	c.custom[path] = true
 	c.ProtectedPaths = append(c.ProtectedPaths, path)
 }
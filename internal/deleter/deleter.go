@@ -2,10 +2,13 @@
 package deleter
 
 import (
+	"context"
 	"crypto/rand"
 	"os"
+	"path/filepath"
 	"sort"
-	"sync"
+	"strings"
+	"time"
 
 	"nuke/internal/scanner"
 	"nuke/internal/trash"
@@ -16,6 +19,10 @@ type Deleter struct {
 	workers  int            // Number of concurrent workers
 	shred    bool           // Whether to securely shred files
 	trashMgr *trash.Manager // Trash manager for soft delete
+	tree     *TreeOptions   // Fast path for scanned trees
+	retry    RetryPolicy    // Handling of transient failures
+
+	treeEntries map[string]*trash.TreeEntry // Trash entries of the current Delete, by scan root
 }
 
 // New creates a new Deleter
@@ -27,91 +34,156 @@ func New(workers int, shred bool, trashMgr *trash.Manager) *Deleter {
 		workers:  workers,
 		shred:    shred,
 		trashMgr: trashMgr,
+		retry:    DefaultRetryPolicy(),
 	}
 }
 
-// ProgressCallback is called for each file processed
-type ProgressCallback func(path string, err error)
+// Action describes what the deleter did with a file
+type Action string
 
-// Delete deletes the given files concurrently
-func (d *Deleter) Delete(files []scanner.FileInfo, onProgress ProgressCallback) {
-	// Separate files and directories
-	var regularFiles []scanner.FileInfo
-	var directories []scanner.FileInfo
+// Actions reported in a Result
+const (
+	ActionTrashed  Action = "trashed"
+	ActionShredded Action = "shredded"
+	ActionDeleted  Action = "deleted"
+	ActionSkipped  Action = "skipped"
+	ActionFailed   Action = "failed"
+)
 
+// Result describes the outcome of processing a single file
+type Result struct {
+	Path     string        // Path that was processed
+	IsDir    bool          // Whether the path was a directory
+	Action   Action        // What was done with the path
+	Bytes    int64         // Bytes freed or moved to trash
+	TrashID  string        // Trash entry ID when the file was trashed
+	Duration time.Duration // Time spent on the operation
+	Err      error         // Error if the operation failed
+	Class    ErrorClass    // Classification of Err
+	Attempts int           // Number of attempts made, more than 1 if retried
+}
+
+// ProgressCallback is called with the result of each file processed
+type ProgressCallback func(Result)
+
+// Delete deletes the given files concurrently. Directories are removed as
+// soon as everything planned beneath them is done; if anything beneath a
+// directory fails it is left in place and reported as skipped.
+// When ctx is cancelled no new work is dispatched; operations already in
+// flight are allowed to finish. The files that were never attempted are
+// returned so the caller can report or save them.
+func (d *Deleter) Delete(ctx context.Context, files []scanner.FileInfo, onProgress ProgressCallback) []scanner.FileInfo {
+	// Hard-delete recursively scanned trees with the fast path
+	var treePending []scanner.FileInfo
+	if d.tree != nil && d.trashMgr == nil && !d.shred {
+		var trees map[string][]scanner.FileInfo
+		trees, files = splitTrees(files)
+		treePending = d.removeTrees(ctx, trees, onProgress)
+	}
+
+	// Trash recursively scanned trees as single entries
+	if d.tree != nil && d.trashMgr != nil && !d.shred {
+		files, treePending = d.trashTrees(ctx, files, onProgress)
+		defer d.closeTrees()
+	}
+
+	// The scheduler needs every directory to arrive after everything
+	// planned beneath it. Scans already produce that order; anything else
+	// is sorted deepest first.
+	ordered := make([]scanner.FileInfo, 0, len(files))
+	seen := make(map[string]bool, len(files))
 	for _, f := range files {
-		if f.IsDir {
-			directories = append(directories, f)
-		} else {
-			regularFiles = append(regularFiles, f)
+		if !seen[f.Path] {
+			seen[f.Path] = true
+			ordered = append(ordered, f)
 		}
 	}
+	if !postOrder(ordered) {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return depth(ordered[i].Path) > depth(ordered[j].Path)
+		})
+	}
 
-	// Delete regular files concurrently
-	d.deleteFilesConcurrently(regularFiles, onProgress)
+	in := make(chan scanner.FileInfo)
+	go func() {
+		defer close(in)
+		for _, f := range ordered {
+			in <- f
+		}
+	}()
 
-	// Delete directories in order (deepest first)
-	// Sort directories by depth (deepest first)
-	sort.Slice(directories, func(i, j int) bool {
-		return len(directories[i].Path) > len(directories[j].Path)
-	})
+	return append(treePending, d.run(ctx, in, onProgress)...)
+}
 
-	for _, dir := range directories {
-		err := d.deleteDirectory(dir)
-		if onProgress != nil {
-			onProgress(dir.Path, err)
+// postOrder reports whether no path is preceded by one of its ancestors
+func postOrder(files []scanner.FileInfo) bool {
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		for dir := filepath.Dir(f.Path); ; dir = filepath.Dir(dir) {
+			if seen[dir] {
+				return false
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
 		}
+		seen[f.Path] = true
 	}
+	return true
 }
 
-// deleteFilesConcurrently deletes files using multiple workers
-func (d *Deleter) deleteFilesConcurrently(files []scanner.FileInfo, onProgress ProgressCallback) {
-	if len(files) == 0 {
-		return
-	}
+// depth returns the number of path separators in a path
+func depth(path string) int {
+	return strings.Count(path, string(filepath.Separator))
+}
 
-	// Create work channel
-	workChan := make(chan scanner.FileInfo, len(files))
-
-	// Create wait group
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < d.workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for file := range workChan {
-				var err error
-				if d.shred {
-					err = d.shredFile(file)
-				} else {
-					err = d.softDelete(file)
-				}
-				if onProgress != nil {
-					onProgress(file.Path, err)
-				}
-			}
-		}()
-	}
+// process deletes a single file or directory and describes the outcome.
+// Transient failures are handled according to the retry policy.
+func (d *Deleter) process(ctx context.Context, file scanner.FileInfo) Result {
+	start := time.Now()
+	res := Result{Path: file.Path, IsDir: file.IsDir, Bytes: file.Size}
 
-	// Send work to workers
-	for _, file := range files {
-		workChan <- file
+	attempts, skipped, err := d.retry.do(ctx, file.Path, file.IsDir, func() error {
+		return d.attempt(file, &res)
+	})
+	res.Attempts = attempts
+
+	res.Duration = time.Since(start)
+	if err != nil {
+		if skipped {
+			res.Action = ActionSkipped
+		} else if res.Action != ActionSkipped {
+			res.Action = ActionFailed
+		}
+		res.Bytes = 0
+		res.TrashID = ""
+		res.Err = err
+		res.Class = classifyPath(file.Path, err)
 	}
-	close(workChan)
 
-	// Wait for all workers to complete
-	wg.Wait()
+	return res
 }
 
-// softDelete moves a file to trash
-func (d *Deleter) softDelete(file scanner.FileInfo) error {
-	if d.trashMgr == nil {
-		// Fall back to hard delete if no trash manager
+// attempt makes a single attempt at deleting a file, setting the action
+// and trash ID of res
+func (d *Deleter) attempt(file scanner.FileInfo, res *Result) error {
+	tree := d.treeEntries[file.Root]
+	switch {
+	case tree != nil:
+		return d.trashIntoTree(tree, file, res)
+	case d.trashMgr == nil && !d.shred, file.IsDir && d.shred:
+		// Hard delete if no trash manager; directories are never shredded
+		res.Action = ActionDeleted
 		return os.Remove(file.Path)
+	case d.shred:
+		res.Action = ActionShredded
+		return d.shredFile(file)
+	default:
+		entry, err := d.trashMgr.MoveToTrashEntry(file.Path)
+		res.Action = ActionTrashed
+		res.TrashID = entry.ID()
+		return err
 	}
-	return d.trashMgr.MoveToTrash(file.Path)
 }
 
 // shredFile securely overwrites and deletes a file
@@ -170,23 +242,7 @@ func (d *Deleter) shredFile(file scanner.FileInfo) error {
 	return os.Remove(file.Path)
 }
 
-// deleteDirectory removes a directory
-func (d *Deleter) deleteDirectory(dir scanner.FileInfo) error {
-	if d.trashMgr == nil || d.shred {
-		// Hard delete for shred mode or if no trash manager
-		return os.Remove(dir.Path)
-	}
-	return d.trashMgr.MoveToTrash(dir.Path)
-}
-
 // DeleteSingle deletes a single file
 func (d *Deleter) DeleteSingle(file scanner.FileInfo) error {
-	if file.IsDir {
-		return d.deleteDirectory(file)
-	}
-
-	if d.shred {
-		return d.shredFile(file)
-	}
-	return d.softDelete(file)
+	return d.process(context.Background(), file).Err
 }
@@ -1,11 +1,17 @@
 package deleter
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"nuke/internal/filter"
 	"nuke/internal/scanner"
+	"nuke/internal/trash"
 )
 
 func TestDeleter(t *testing.T) {
@@ -33,9 +39,12 @@ func TestDeleter(t *testing.T) {
 
 	// Test regular deletion
 	d := New(2, false, nil)
-	d.Delete(files, func(path string, err error) {
-		if err != nil {
-			t.Errorf("failed to delete %s: %v", path, err)
+	d.Delete(context.Background(), files, func(res Result) {
+		if res.Err != nil {
+			t.Errorf("failed to delete %s: %v", res.Path, res.Err)
+		}
+		if res.Action != ActionDeleted {
+			t.Errorf("expected action %q for %s, got %q", ActionDeleted, res.Path, res.Action)
 		}
 	})
 
@@ -57,9 +66,12 @@ func TestDeleter(t *testing.T) {
 	}
 
 	dShred := New(1, true, nil)
-	dShred.Delete(files, func(path string, err error) {
-		if err != nil {
-			t.Errorf("failed to shred %s: %v", path, err)
+	dShred.Delete(context.Background(), files, func(res Result) {
+		if res.Err != nil {
+			t.Errorf("failed to shred %s: %v", res.Path, res.Err)
+		}
+		if res.Action != ActionShredded {
+			t.Errorf("expected action %q for %s, got %q", ActionShredded, res.Path, res.Action)
 		}
 	})
 
@@ -68,3 +80,403 @@ func TestDeleter(t *testing.T) {
 		t.Errorf("expected file3 to be gone after shredding")
 	}
 }
+
+func TestDeleteCancelled(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-cancel-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	subDir := filepath.Join(tmpDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("failed to create subdir: %v", err)
+	}
+	file1 := filepath.Join(subDir, "file1.txt")
+	if err := os.WriteFile(file1, []byte("test1"), 0644); err != nil {
+		t.Fatalf("failed to write file1: %v", err)
+	}
+
+	files := []scanner.FileInfo{
+		{Path: file1, IsDir: false, Size: 5},
+		{Path: subDir, IsDir: true},
+	}
+
+	// A cancelled context must not dispatch any work
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	d := New(2, false, nil)
+	pending := d.Delete(ctx, files, func(res Result) {
+		t.Errorf("unexpected progress for %s after cancellation", res.Path)
+	})
+
+	if len(pending) != len(files) {
+		t.Errorf("expected %d pending files, got %d", len(files), len(pending))
+	}
+	if _, err := os.Stat(file1); err != nil {
+		t.Errorf("expected file1 to still exist: %v", err)
+	}
+}
+
+func TestDeleteReportsErrorClass(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-class-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	missing := filepath.Join(tmpDir, "missing.txt")
+	files := []scanner.FileInfo{{Path: missing, Size: 5}}
+
+	var got []Result
+	New(1, false, nil).Delete(context.Background(), files, func(res Result) {
+		got = append(got, res)
+	})
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(got))
+	}
+	if got[0].Action != ActionFailed || got[0].Class != ClassNotFound {
+		t.Errorf("expected failed/not-found, got %s/%s", got[0].Action, got[0].Class)
+	}
+	if got[0].Bytes != 0 {
+		t.Errorf("expected no bytes freed for a failure, got %d", got[0].Bytes)
+	}
+}
+
+func TestDeleteTreeFastPath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-tree-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// root/{a/x.log, a/b/y.log, a/keep.txt, c/z.log, protected/p.log}
+	root := filepath.Join(tmpDir, "root")
+	for _, dir := range []string{"a/b", "c", "protected"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	for _, name := range []string{"a/x.log", "a/b/y.log", "a/keep.txt", "c/z.log", "protected/p.log"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	exclude, err := filter.ParsePatterns([]string{"*.txt"})
+	if err != nil {
+		t.Fatalf("failed to parse pattern: %v", err)
+	}
+	filterOpts := &filter.Options{Exclude: exclude}
+	files, err := scanner.Scan(root, true, filterOpts)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	// Files created after the plan was made are not part of it, even if
+	// they match the filters
+	for _, dir := range []string{"late", "c/late"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "new.log"), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s/new.log: %v", dir, err)
+		}
+	}
+	planned := make(map[string]bool)
+	for _, f := range files {
+		planned[f.Path] = true
+	}
+
+	d := New(4, false, nil)
+	d.SetTreeOptions(&TreeOptions{
+		Filter: filterOpts,
+		Protected: func(path string) bool {
+			return filepath.Base(path) == "protected"
+		},
+	})
+
+	var mu sync.Mutex
+	actions := make(map[string]Action)
+	d.Delete(context.Background(), files, func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !planned[res.Path] {
+			t.Errorf("reported %s, which was not planned", res.Path)
+		}
+		actions[res.Path] = res.Action
+	})
+
+	for _, name := range []string{"a/x.log", "a/b/y.log", "a/b", "c/z.log"} {
+		path := filepath.Join(root, name)
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+		if actions[path] != ActionDeleted {
+			t.Errorf("expected %s to be reported as deleted, got %q", name, actions[path])
+		}
+	}
+	for _, name := range []string{"a/keep.txt", "protected/p.log", "late/new.log", "c/late/new.log"} {
+		if _, err := os.Lstat(filepath.Join(root, name)); err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+	if actions[filepath.Join(root, "protected")] != ActionSkipped {
+		t.Errorf("expected protected directory to be reported as skipped")
+	}
+	if actions[filepath.Join(root, "c")] != ActionSkipped {
+		t.Errorf("expected c, now holding new files, to be reported as skipped, got %q", actions[filepath.Join(root, "c")])
+	}
+	if actions[root] != ActionSkipped {
+		t.Errorf("expected non-empty root to be reported as skipped, got %q", actions[root])
+	}
+}
+
+// untyped hides the type of every entry, as filesystems that report
+// DT_UNKNOWN do
+type untyped struct {
+	dirHandle
+}
+
+func (u untyped) entries() ([]dirent, error) {
+	entries, err := u.dirHandle.entries()
+	for i := range entries {
+		entries[i].isDir = false
+	}
+	return entries, err
+}
+
+func (u untyped) open(name string) (dirHandle, error) {
+	sub, err := u.dirHandle.open(name)
+	if err != nil {
+		return nil, err
+	}
+	return untyped{sub}, nil
+}
+
+func TestDeleteTreeUntypedEntries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-tree-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// keep is not planned itself but holds a planned file
+	keep := filepath.Join(tmpDir, "keep")
+	if err := os.Mkdir(keep, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	for _, name := range []string{"a.log", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(keep, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	planned := filepath.Join(keep, "a.log")
+
+	var results []Result
+	w := &treeWalker{
+		ctx:        context.Background(),
+		opts:       &TreeOptions{},
+		sem:        make(chan struct{}, 1),
+		retry:      DefaultRetryPolicy(),
+		onProgress: func(r Result) { results = append(results, r) },
+	}
+	w.plan(tmpDir, []scanner.FileInfo{{Path: planned, Root: tmpDir}})
+	dir, err := openRoot(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to open root: %v", err)
+	}
+	defer func() { _ = dir.close() }()
+
+	if w.walk(untyped{dir}, tmpDir) {
+		t.Error("expected the root to keep entries")
+	}
+	if len(results) != 1 || results[0].Path != planned || results[0].Action != ActionDeleted {
+		t.Errorf("expected only %s to be deleted, got %+v", planned, results)
+	}
+	if _, err := os.Stat(planned); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", planned)
+	}
+	if _, err := os.Stat(filepath.Join(keep, "b.txt")); err != nil {
+		t.Errorf("expected unplanned file to stay: %v", err)
+	}
+}
+
+func TestDeleteDirectoryOrdering(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-order-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// ok/{a/b/file, c/file} can be removed completely; blocked/stuck is a
+	// non-empty directory planned as a file, so removing it fails
+	root := filepath.Join(tmpDir, "root")
+	for _, dir := range []string{"ok/a/b", "ok/c", "blocked/stuck"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	for _, name := range []string{"ok/a/b/file", "ok/c/file", "blocked/stuck/inner"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	// Deliberately shallow-first so the scheduler has to reorder
+	var files []scanner.FileInfo
+	for _, dir := range []string{"", "ok", "blocked", "ok/a", "ok/c", "ok/a/b"} {
+		files = append(files, scanner.FileInfo{Path: filepath.Join(root, dir), IsDir: true})
+	}
+	for _, name := range []string{"ok/a/b/file", "ok/c/file", "blocked/stuck"} {
+		files = append(files, scanner.FileInfo{Path: filepath.Join(root, name)})
+	}
+
+	var mu sync.Mutex
+	actions := make(map[string]Action)
+	New(4, false, nil).Delete(context.Background(), files, func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		actions[res.Path] = res.Action
+	})
+
+	if _, err := os.Lstat(filepath.Join(root, "ok")); !os.IsNotExist(err) {
+		t.Errorf("expected ok/ to be removed completely")
+	}
+	if actions[filepath.Join(root, "blocked/stuck")] != ActionFailed {
+		t.Errorf("expected blocked/stuck to fail")
+	}
+	for _, dir := range []string{"blocked", ""} {
+		path := filepath.Join(root, dir)
+		if actions[path] != ActionSkipped {
+			t.Errorf("expected %s to be skipped, got %q", path, actions[path])
+		}
+		if _, err := os.Lstat(path); err != nil {
+			t.Errorf("expected %s to be left in place: %v", path, err)
+		}
+	}
+}
+
+func TestDeleteTrashesTreeAsOneEntry(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-trash-tree-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	trashMgr, err := trash.NewManagerAt(filepath.Join(tmpDir, "trash"))
+	if err != nil {
+		t.Fatalf("failed to create trash manager: %v", err)
+	}
+
+	root := filepath.Join(tmpDir, "root")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0755); err != nil {
+		t.Fatalf("failed to create tree: %v", err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	files, err := scanner.Scan(root, true, nil)
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	d := New(4, false, trashMgr)
+	d.SetTreeOptions(&TreeOptions{})
+
+	var mu sync.Mutex
+	ids := make(map[string]bool)
+	d.Delete(context.Background(), files, func(res Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		if res.Action != ActionTrashed {
+			t.Errorf("expected %s to be trashed, got %s: %v", res.Path, res.Action, res.Err)
+		}
+		ids[res.TrashID] = true
+	})
+
+	if len(ids) != 1 {
+		t.Errorf("expected all files to share one trash entry, got %d", len(ids))
+	}
+	entries, _, err := trashMgr.List()
+	if err != nil {
+		t.Fatalf("failed to list trash: %v", err)
+	}
+	if len(entries) != 1 || entries[0].OriginalPath != root || entries[0].Partial {
+		t.Fatalf("expected root trashed as a whole, got %+v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(entries[0].TrashPath, "sub", "b.txt")); err != nil {
+		t.Errorf("expected tree structure preserved in trash: %v", err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	// busyTimes returns an operation that is busy n times before succeeding
+	busyTimes := func(n int) func() error {
+		return func() error {
+			if n > 0 {
+				n--
+				return &os.PathError{Op: "remove", Path: "x", Err: syscall.EBUSY}
+			}
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	retry := RetryPolicy{OnBusy: BusyRetry, Attempts: 3}
+	if attempts, skipped, err := retry.do(ctx, "x", false, busyTimes(2)); err != nil || skipped || attempts != 3 {
+		t.Errorf("retry: expected success after 3 attempts, got %d, %v, %v", attempts, skipped, err)
+	}
+	if attempts, _, err := retry.do(ctx, "x", false, busyTimes(5)); Classify(err) != ClassBusy || attempts != 3 {
+		t.Errorf("retry: expected busy failure after 3 attempts, got %d, %v", attempts, err)
+	}
+
+	skip := RetryPolicy{OnBusy: BusySkip, Attempts: 3}
+	if attempts, skipped, err := skip.do(ctx, "x", false, busyTimes(1)); err == nil || !skipped || attempts != 1 {
+		t.Errorf("skip: expected immediate skip, got %d, %v, %v", attempts, skipped, err)
+	}
+
+	fail := RetryPolicy{OnBusy: BusyFail, Attempts: 3}
+	if attempts, skipped, err := fail.do(ctx, "x", false, busyTimes(1)); err == nil || skipped || attempts != 1 {
+		t.Errorf("fail: expected immediate failure, got %d, %v, %v", attempts, skipped, err)
+	}
+
+	// Permanent errors are never retried
+	calls := 0
+	_, _, err := retry.do(ctx, "x", false, func() error {
+		calls++
+		return os.ErrPermission
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("expected permanent error without retries, got %d calls", calls)
+	}
+
+	// Cancelling stops the wait before the next attempt
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	slow := RetryPolicy{OnBusy: BusyRetry, Attempts: 3, Backoff: time.Hour}
+	if attempts, skipped, err := slow.do(cancelled, "x", false, busyTimes(5)); Classify(err) != ClassBusy || skipped || attempts != 1 {
+		t.Errorf("cancelled: expected busy failure after 1 attempt, got %d, %v, %v", attempts, skipped, err)
+	}
+
+	// A directory holding only NFS silly-renamed files is transient
+	tmpDir, err := os.MkdirTemp("", "nuke-deleter-retry-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".nfs0000000012345"), nil, 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if !transient(tmpDir, true, syscall.ENOTEMPTY) {
+		t.Errorf("expected silly-renamed directory to be transient")
+	}
+	if transient(tmpDir, false, syscall.ENOTEMPTY) {
+		t.Errorf("expected non-directory not-empty error to be permanent")
+	}
+}
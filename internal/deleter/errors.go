@@ -0,0 +1,67 @@
+package deleter
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+
+	"nuke/internal/preflight"
+)
+
+// ErrorClass groups deletion errors by cause
+type ErrorClass string
+
+// Error classes reported in a Result
+const (
+	ClassNone       ErrorClass = ""
+	ClassPermission ErrorClass = "permission"
+	ClassBusy       ErrorClass = "busy"
+	ClassNotFound   ErrorClass = "not-found"
+	ClassReadOnly   ErrorClass = "read-only"
+	ClassNotEmpty   ErrorClass = "not-empty"
+	ClassProtected  ErrorClass = "protected"
+	ClassImmutable  ErrorClass = "immutable"
+	ClassAppendOnly ErrorClass = "append-only"
+	ClassOther      ErrorClass = "other"
+)
+
+// Classify returns the class of a deletion error
+func Classify(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return ClassNone
+	case errors.Is(err, ErrProtected):
+		return ClassProtected
+	case errors.Is(err, ErrNotEmpty):
+		return ClassNotEmpty
+	case errors.Is(err, fs.ErrNotExist):
+		return ClassNotFound
+	case errors.Is(err, syscall.EROFS):
+		return ClassReadOnly
+	case errors.Is(err, fs.ErrPermission):
+		return ClassPermission
+	case errors.Is(err, syscall.EBUSY), errors.Is(err, syscall.ETXTBSY), errors.Is(err, syscall.EAGAIN):
+		return ClassBusy
+	case errors.Is(err, syscall.ENOTEMPTY), errors.Is(err, syscall.EEXIST):
+		return ClassNotEmpty
+	default:
+		return ClassOther
+	}
+}
+
+// classifyPath classifies the error of a failed path, telling immutable and
+// append-only attributes apart from ordinary permission errors
+func classifyPath(path string, err error) ErrorClass {
+	class := Classify(err)
+	if class != ClassPermission {
+		return class
+	}
+	switch preflight.Attribute(path) {
+	case preflight.KindImmutable:
+		return ClassImmutable
+	case preflight.KindAppendOnly:
+		return ClassAppendOnly
+	default:
+		return class
+	}
+}
@@ -0,0 +1,113 @@
+package deleter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// BusyPolicy decides what happens to files that fail with a transient error
+type BusyPolicy string
+
+// Policies accepted by --on-busy
+const (
+	BusyRetry BusyPolicy = "retry" // Retry with backoff, then fail
+	BusySkip  BusyPolicy = "skip"  // Leave the file in place and report it as skipped
+	BusyFail  BusyPolicy = "fail"  // Fail immediately
+)
+
+// ParseBusyPolicy parses an --on-busy value
+func ParseBusyPolicy(s string) (BusyPolicy, error) {
+	switch p := BusyPolicy(strings.ToLower(s)); p {
+	case BusyRetry, BusySkip, BusyFail:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown policy %q (expected skip, retry or fail)", s)
+	}
+}
+
+// RetryPolicy configures how transient failures are handled
+type RetryPolicy struct {
+	OnBusy   BusyPolicy    // What to do with busy files
+	Attempts int           // Total attempts per file when retrying
+	Backoff  time.Duration // Delay before the first retry, doubled after each
+}
+
+// DefaultRetryPolicy returns the policy used unless SetRetryPolicy is called
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{OnBusy: BusyRetry, Attempts: 3, Backoff: 100 * time.Millisecond}
+}
+
+// SetRetryPolicy sets how transient failures are handled
+func (d *Deleter) SetRetryPolicy(p RetryPolicy) {
+	if p.OnBusy == "" {
+		p.OnBusy = BusyRetry
+	}
+	if p.Attempts <= 0 {
+		p.Attempts = 1
+	}
+	d.retry = p
+}
+
+// do runs op until it succeeds, fails permanently or runs out of attempts.
+// It returns the number of attempts made, whether the file should be
+// reported as skipped rather than failed, and the last error. Retries stop
+// once ctx is cancelled.
+func (p RetryPolicy) do(ctx context.Context, path string, isDir bool, op func() error) (attempts int, skipped bool, err error) {
+	delay := p.Backoff
+	for {
+		attempts++
+		err = op()
+		if err == nil || !transient(path, isDir, err) {
+			return attempts, false, err
+		}
+
+		switch p.OnBusy {
+		case BusySkip:
+			return attempts, true, err
+		case BusyFail:
+			return attempts, false, err
+		}
+		if attempts >= p.Attempts {
+			return attempts, false, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempts, false, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// transient reports whether an error may go away on its own: the file is
+// busy, or a directory only holds files an NFS client renamed because they
+// were still open (.nfsXXXX silly renames)
+func transient(path string, isDir bool, err error) bool {
+	switch Classify(err) {
+	case ClassBusy:
+		return true
+	case ClassNotEmpty:
+		return isDir && onlySillyRenames(path)
+	default:
+		return false
+	}
+}
+
+// onlySillyRenames reports whether a directory is non-empty only because of
+// NFS silly-renamed files
+func onlySillyRenames(path string) bool {
+	entries, err := os.ReadDir(path)
+	if err != nil || len(entries) == 0 {
+		return false
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".nfs") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"nuke/internal/scanner"
+)
+
+// ErrChildFailed is the reason a directory is left in place when something
+// beneath it could not be removed
+var ErrChildFailed = errors.New("left in place because entries beneath it were not removed")
+
+// nodeState tracks a path while entries beneath it are outstanding
+type nodeState struct {
+	pending int              // Planned descendants that have not finished
+	blocked bool             // A descendant failed or was skipped
+	sealed  bool             // The path itself has been planned
+	entry   scanner.FileInfo // Planned entry, valid once sealed
+}
+
+// outcome is sent from a worker back to the scheduler
+type outcome struct {
+	file scanner.FileInfo
+	res  Result
+}
+
+// scheduler hands files to workers so that a path is only processed once
+// everything planned beneath it has finished. Input must arrive in
+// post-order (descendants before their directory), which lets directories
+// be dispatched as soon as their subtree is done without a global barrier.
+type scheduler struct {
+	d          *Deleter
+	onProgress ProgressCallback
+	nodes      map[string]*nodeState
+	ready      []scanner.FileInfo
+}
+
+// run consumes files from in until it is closed or ctx is cancelled and
+// returns the files that were never attempted
+func (d *Deleter) run(ctx context.Context, in <-chan scanner.FileInfo, onProgress ProgressCallback) []scanner.FileInfo {
+	s := &scheduler{
+		d:          d,
+		onProgress: onProgress,
+		nodes:      make(map[string]*nodeState),
+	}
+
+	work := make(chan scanner.FileInfo)
+	done := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range work {
+				done <- outcome{file: file, res: d.process(ctx, file)}
+			}
+		}()
+	}
+
+	inflight := 0
+	inputOpen := true
+	for {
+		cancelled := ctx.Err() != nil
+
+		// Only pull more input and dispatch while not cancelled
+		var inCh <-chan scanner.FileInfo
+		if inputOpen && !cancelled {
+			inCh = in
+		}
+		var workCh chan scanner.FileInfo
+		var next scanner.FileInfo
+		if len(s.ready) > 0 && !cancelled {
+			workCh = work
+			next = s.ready[0]
+		}
+		var ctxDone <-chan struct{}
+		if !cancelled {
+			ctxDone = ctx.Done()
+		}
+
+		if inflight == 0 && inCh == nil && workCh == nil {
+			break
+		}
+
+		select {
+		case f, ok := <-inCh:
+			if !ok {
+				inputOpen = false
+				continue
+			}
+			s.add(f)
+		case workCh <- next:
+			s.ready = s.ready[1:]
+			inflight++
+		case o := <-done:
+			inflight--
+			if onProgress != nil {
+				onProgress(o.res)
+			}
+			s.finish(o.file, blocksParent(o.res))
+		case <-ctxDone:
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	// Everything queued, waiting on descendants or not yet received was
+	// never attempted
+	pending := append([]scanner.FileInfo{}, s.ready...)
+	for _, node := range s.nodes {
+		if node.sealed {
+			pending = append(pending, node.entry)
+		}
+	}
+	if inputOpen {
+		for f := range in {
+			pending = append(pending, f)
+		}
+	}
+
+	return pending
+}
+
+// blocksParent reports whether a result leaves something behind that
+// prevents the parent directory from being removed
+func blocksParent(res Result) bool {
+	switch res.Action {
+	case ActionSkipped:
+		return true
+	case ActionFailed:
+		// Already gone is as good as removed
+		return res.Class != ClassNotFound
+	default:
+		return false
+	}
+}
+
+// add registers a planned file with all of its ancestors
+func (s *scheduler) add(f scanner.FileInfo) {
+	forEachAncestor(f.Path, func(dir string) {
+		s.node(dir).pending++
+	})
+
+	node := s.node(f.Path)
+	node.sealed = true
+	node.entry = f
+	s.tryDispatch(f.Path, node)
+}
+
+// finish releases the ancestors of a processed file. A failed or skipped
+// file blocks all of its ancestors from being removed.
+func (s *scheduler) finish(f scanner.FileInfo, failed bool) {
+	var settled []string
+	forEachAncestor(f.Path, func(dir string) {
+		node := s.nodes[dir]
+		node.pending--
+		if failed {
+			node.blocked = true
+		}
+		if node.pending > 0 {
+			return
+		}
+		if node.sealed {
+			settled = append(settled, dir)
+		} else if !node.blocked {
+			delete(s.nodes, dir)
+		}
+	})
+
+	for _, dir := range settled {
+		if node, ok := s.nodes[dir]; ok {
+			s.tryDispatch(dir, node)
+		}
+	}
+}
+
+// tryDispatch queues a planned path once nothing beneath it is outstanding.
+// Blocked directories are reported as skipped and block their own ancestors.
+func (s *scheduler) tryDispatch(path string, node *nodeState) {
+	if !node.sealed || node.pending > 0 {
+		return
+	}
+	delete(s.nodes, path)
+
+	if node.blocked {
+		if s.onProgress != nil {
+			s.onProgress(Result{
+				Path:   path,
+				IsDir:  node.entry.IsDir,
+				Action: ActionSkipped,
+				Err:    ErrChildFailed,
+				Class:  ClassNotEmpty,
+			})
+		}
+		s.finish(node.entry, true)
+		return
+	}
+
+	s.ready = append(s.ready, node.entry)
+}
+
+// node returns the state of a path, creating it if needed
+func (s *scheduler) node(path string) *nodeState {
+	node, ok := s.nodes[path]
+	if !ok {
+		node = &nodeState{}
+		s.nodes[path] = node
+	}
+	return node
+}
+
+// forEachAncestor calls fn for every parent directory of path, nearest first
+func forEachAncestor(path string, fn func(dir string)) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		fn(dir)
+		if dir == filepath.Dir(dir) {
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package deleter
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"nuke/internal/scanner"
+	"nuke/internal/trash"
+)
+
+// trashTrees moves recursively scanned trees to the trash as single
+// entries. A tree planned in full is moved with one rename; a filtered
+// selection is collected in a tree entry that mirrors the directory
+// structure, and its files are returned with the rest so the scheduler
+// moves them in dependency order. Files of trees that were not attempted
+// because ctx was cancelled are returned as pending.
+func (d *Deleter) trashTrees(ctx context.Context, files []scanner.FileInfo, onProgress ProgressCallback) (rest, pending []scanner.FileInfo) {
+	trees, rest := splitTrees(files)
+
+	roots := make([]string, 0, len(trees))
+	for root := range trees {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+
+	d.treeEntries = make(map[string]*trash.TreeEntry)
+	for _, root := range roots {
+		planned := trees[root]
+		if ctx.Err() != nil {
+			pending = append(pending, planned...)
+			continue
+		}
+
+		if d.wholeTree(root, planned) {
+			start := time.Now()
+			entry, err := d.trashMgr.MoveToTrashEntry(root)
+			if err == nil {
+				d.reportTree(root, planned, entry.ID(), time.Since(start), onProgress)
+				continue
+			}
+			// Fall back to moving the files one by one
+		}
+
+		t, err := d.trashMgr.BeginTree(root)
+		if err == nil {
+			d.treeEntries[root] = t
+		}
+		rest = append(rest, planned...)
+	}
+
+	return rest, pending
+}
+
+// closeTrees finalizes the tree entries created by trashTrees
+func (d *Deleter) closeTrees() {
+	for _, t := range d.treeEntries {
+		_ = t.Close()
+	}
+	d.treeEntries = nil
+}
+
+// wholeTree reports whether everything beneath root was planned, so the
+// root can be trashed as it is
+func (d *Deleter) wholeTree(root string, planned []scanner.FileInfo) bool {
+	if d.tree.Filter.Active() {
+		return false
+	}
+
+	hasRoot := false
+	for _, f := range planned {
+		if f.Path == root {
+			hasRoot = true
+		}
+		if d.tree.Protected != nil && d.tree.Protected(f.Path) {
+			return false
+		}
+	}
+	return hasRoot
+}
+
+// reportTree reports every planned file of a tree that was trashed whole
+func (d *Deleter) reportTree(root string, planned []scanner.FileInfo, trashID string, took time.Duration, onProgress ProgressCallback) {
+	if onProgress == nil {
+		return
+	}
+	for _, f := range planned {
+		res := Result{Path: f.Path, IsDir: f.IsDir, Action: ActionTrashed, Bytes: f.Size, TrashID: trashID}
+		if f.Path == root {
+			res.Duration = took
+		}
+		onProgress(res)
+	}
+}
+
+// trashIntoTree moves a file into the tree entry of its scan root
+func (d *Deleter) trashIntoTree(t *trash.TreeEntry, file scanner.FileInfo, res *Result) error {
+	if d.tree.Protected != nil && d.tree.Protected(file.Path) {
+		res.Action = ActionSkipped
+		return ErrProtected
+	}
+
+	err := t.Move(file.Path)
+	if file.IsDir && err != nil && Classify(err) == ClassNotEmpty {
+		// Unselected contents keep the directory in place
+		res.Action = ActionSkipped
+		return ErrNotEmpty
+	}
+
+	res.Action = ActionTrashed
+	res.TrashID = t.Entry().ID()
+	return err
+}
@@ -0,0 +1,336 @@
+package deleter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nuke/internal/filter"
+	"nuke/internal/scanner"
+)
+
+// Reasons attached to skipped results
+var (
+	ErrProtected = errors.New("protected path")
+	ErrNotEmpty  = errors.New("directory still contains entries that were not removed")
+)
+
+// TreeOptions configures how recursively scanned directory trees are
+// removed. Hard deletes remove the planned entries of a tree relative to
+// directory file descriptors instead of by full path; trashing moves each
+// tree into a single trash entry. The same filters and protected paths used
+// for scanning must be supplied.
+type TreeOptions struct {
+	Filter    *filter.Options        // Filters the scan used (nil matches everything)
+	Protected func(path string) bool // Reports paths that must never be removed
+	Skip      func(path string) bool // Reports directories the scan did not enter
+}
+
+// SetTreeOptions enables tree removal for hard deletes and trashing.
+// It has no effect when files are shredded.
+func (d *Deleter) SetTreeOptions(opts *TreeOptions) {
+	d.tree = opts
+}
+
+// dirent is a directory entry returned by a dirHandle
+type dirent struct {
+	name  string
+	isDir bool
+}
+
+// dirHandle is an open directory. On Linux it wraps a directory file
+// descriptor so every operation is relative to it; elsewhere it falls back
+// to path based calls.
+type dirHandle interface {
+	entries() ([]dirent, error)
+	stat(name string) (os.FileInfo, error)
+	open(name string) (dirHandle, error)
+	remove(name string, isDir bool) error
+	close() error
+}
+
+// splitTrees separates files belonging to recursively scanned directory
+// roots from the rest. Roots are only recognised when the scan descended
+// into them, so non-recursive directory targets are never walked.
+func splitTrees(files []scanner.FileInfo) (map[string][]scanner.FileInfo, []scanner.FileInfo) {
+	roots := make(map[string]bool)
+	for _, f := range files {
+		if f.Root != "" && f.Path != f.Root {
+			roots[f.Root] = true
+		}
+	}
+
+	trees := make(map[string][]scanner.FileInfo)
+	var rest []scanner.FileInfo
+	for _, f := range files {
+		if roots[f.Root] {
+			trees[f.Root] = append(trees[f.Root], f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+
+	return trees, rest
+}
+
+// treeWalker removes the planned contents of a directory tree. Only
+// planned paths are removed and reported: entries created or left out
+// since the scan stay in place, and directories holding nothing planned are
+// not read at all.
+type treeWalker struct {
+	ctx        context.Context
+	opts       *TreeOptions
+	planned    map[string]bool // Paths planned for removal in the current tree
+	parents    map[string]bool // Directories with planned entries beneath them
+	sem        chan struct{}   // Limits the number of subtrees walked in parallel
+	retry      RetryPolicy
+	onProgress ProgressCallback
+}
+
+// removeTrees removes each tree root with the fast path and returns the
+// planned files that were not attempted because ctx was cancelled
+func (d *Deleter) removeTrees(ctx context.Context, trees map[string][]scanner.FileInfo, onProgress ProgressCallback) []scanner.FileInfo {
+	w := &treeWalker{
+		ctx:        ctx,
+		opts:       d.tree,
+		sem:        make(chan struct{}, d.workers),
+		retry:      d.retry,
+		onProgress: onProgress,
+	}
+
+	var pending []scanner.FileInfo
+	for root, planned := range trees {
+		if ctx.Err() == nil {
+			w.plan(root, planned)
+			w.removeRoot(root)
+		}
+		if ctx.Err() != nil {
+			// Whatever still exists was never attempted
+			for _, f := range planned {
+				if _, err := os.Lstat(f.Path); err == nil {
+					pending = append(pending, f)
+				}
+			}
+		}
+	}
+
+	return pending
+}
+
+// plan records the planned entries of a tree and the directories leading
+// to them
+func (w *treeWalker) plan(root string, files []scanner.FileInfo) {
+	w.planned = make(map[string]bool, len(files))
+	w.parents = make(map[string]bool)
+	for _, f := range files {
+		path := filepath.Clean(f.Path)
+		w.planned[path] = true
+		for dir := filepath.Dir(path); path != root && !w.parents[dir]; path, dir = dir, filepath.Dir(dir) {
+			w.parents[dir] = true
+		}
+	}
+}
+
+// removeRoot removes the planned contents of root and root itself if it
+// was planned and ends up empty
+func (w *treeWalker) removeRoot(root string) {
+	planned := w.planned[root]
+	info, err := os.Lstat(root)
+	if err != nil {
+		if planned {
+			w.report(Result{Path: root, IsDir: true}, err)
+		}
+		return
+	}
+	if w.protected(root) {
+		if planned {
+			w.skip(root, true, ErrProtected)
+		}
+		return
+	}
+
+	dir, err := openRoot(root)
+	if err != nil {
+		if planned {
+			w.report(Result{Path: root, IsDir: true}, err)
+		}
+		return
+	}
+	empty := w.walk(dir, root)
+	_ = dir.close()
+
+	if !planned || w.ctx.Err() != nil {
+		return
+	}
+	if !empty {
+		w.skip(root, true, ErrNotEmpty)
+		return
+	}
+
+	start := time.Now()
+	attempts, skipped, err := w.retry.do(w.ctx, root, true, func() error {
+		return os.Remove(root)
+	})
+	w.finish(Result{Path: root, IsDir: true, Bytes: info.Size(), Duration: time.Since(start), Attempts: attempts}, err, skipped)
+}
+
+// walk removes the planned entries of an open directory and reports
+// whether the directory is now empty. Subdirectories are handed to other
+// goroutines while worker slots are free and walked inline otherwise.
+func (w *treeWalker) walk(dir dirHandle, path string) bool {
+	entries, err := dir.entries()
+	if err != nil {
+		w.report(Result{Path: path, IsDir: true}, err)
+		return false
+	}
+
+	var remaining int64
+	var wg sync.WaitGroup
+
+	for _, e := range entries {
+		if w.ctx.Err() != nil {
+			atomic.AddInt64(&remaining, 1)
+			break
+		}
+
+		childPath := filepath.Join(path, e.name)
+		matched := w.planned[childPath]
+		if !matched && !w.parents[childPath] {
+			// Not planned, or created since the scan. Parents of planned
+			// entries are checked to be directories once stat tells.
+			atomic.AddInt64(&remaining, 1)
+			continue
+		}
+		if w.protected(childPath) {
+			if matched {
+				w.skip(childPath, e.isDir, ErrProtected)
+			}
+			atomic.AddInt64(&remaining, 1)
+			continue
+		}
+		if w.opts.Skip != nil && w.opts.Skip(childPath) {
+			// Left in place without a report, as the scan already did
+			atomic.AddInt64(&remaining, 1)
+			continue
+		}
+
+		info, err := dir.stat(e.name)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				if matched {
+					w.report(Result{Path: childPath, IsDir: e.isDir}, err)
+				}
+				atomic.AddInt64(&remaining, 1)
+			}
+			continue
+		}
+
+		if !info.IsDir() {
+			if !matched {
+				atomic.AddInt64(&remaining, 1)
+				continue
+			}
+			start := time.Now()
+			attempts, skipped, err := w.retry.do(w.ctx, childPath, false, func() error {
+				return dir.remove(e.name, false)
+			})
+			w.finish(Result{Path: childPath, Bytes: info.Size(), Duration: time.Since(start), Attempts: attempts}, err, skipped)
+			if err != nil {
+				atomic.AddInt64(&remaining, 1)
+			}
+			continue
+		}
+
+		sub, err := dir.open(e.name)
+		if err != nil {
+			if matched {
+				w.report(Result{Path: childPath, IsDir: true}, err)
+			}
+			atomic.AddInt64(&remaining, 1)
+			continue
+		}
+
+		name := e.name
+		task := func() {
+			empty := w.walk(sub, childPath)
+			_ = sub.close()
+
+			if !matched || w.ctx.Err() != nil {
+				atomic.AddInt64(&remaining, 1)
+				return
+			}
+			if !empty {
+				w.skip(childPath, true, ErrNotEmpty)
+				atomic.AddInt64(&remaining, 1)
+				return
+			}
+
+			start := time.Now()
+			attempts, skipped, err := w.retry.do(w.ctx, childPath, true, func() error {
+				return dir.remove(name, true)
+			})
+			w.finish(Result{Path: childPath, IsDir: true, Bytes: info.Size(), Duration: time.Since(start), Attempts: attempts}, err, skipped)
+			if err != nil {
+				atomic.AddInt64(&remaining, 1)
+			}
+		}
+
+		select {
+		case w.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-w.sem }()
+				task()
+			}()
+		default:
+			task()
+		}
+	}
+
+	wg.Wait()
+	return atomic.LoadInt64(&remaining) == 0
+}
+
+// protected reports whether a path must be left alone
+func (w *treeWalker) protected(path string) bool {
+	return w.opts.Protected != nil && w.opts.Protected(path)
+}
+
+// skip reports an entry that was deliberately left in place
+func (w *treeWalker) skip(path string, isDir bool, reason error) {
+	if w.onProgress == nil {
+		return
+	}
+	w.onProgress(Result{Path: path, IsDir: isDir, Action: ActionSkipped, Err: reason, Class: Classify(reason)})
+}
+
+// finish reports the outcome of a retried removal
+func (w *treeWalker) finish(res Result, err error, skipped bool) {
+	if skipped {
+		if w.onProgress != nil {
+			w.onProgress(Result{Path: res.Path, IsDir: res.IsDir, Action: ActionSkipped, Err: err, Class: Classify(err), Attempts: res.Attempts})
+		}
+		return
+	}
+	w.report(res, err)
+}
+
+// report completes a removal result and passes it to the callback
+func (w *treeWalker) report(res Result, err error) {
+	if w.onProgress == nil {
+		return
+	}
+	res.Action = ActionDeleted
+	if err != nil {
+		res.Action = ActionFailed
+		res.Bytes = 0
+		res.Err = err
+		res.Class = classifyPath(res.Path, err)
+	}
+	w.onProgress(res)
+}
@@ -0,0 +1,160 @@
+//go:build linux
+
+package deleter
+
+import (
+	"encoding/binary"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fdDir is a directory opened as a file descriptor. Entries are read with
+// getdents64 and removed with unlinkat, so no path is resolved more than
+// once no matter how deep the tree is.
+type fdDir struct {
+	fd int
+}
+
+// openRoot opens the root of a tree without following symlinks
+func openRoot(path string) (dirHandle, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return &fdDir{fd: fd}, nil
+}
+
+// entries reads all entries of the directory
+func (d *fdDir) entries() ([]dirent, error) {
+	var entries []dirent
+	buf := make([]byte, 64*1024)
+
+	for {
+		n, err := unix.Getdents(d.fd, buf)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return nil, &os.SyscallError{Syscall: "getdents64", Err: err}
+		}
+		if n <= 0 {
+			return entries, nil
+		}
+		entries = parseDirents(buf[:n], entries, func(name string) bool {
+			info, err := d.stat(name)
+			return err == nil && info.IsDir()
+		})
+	}
+}
+
+// parseDirents decodes linux_dirent64 records:
+// d_ino (8), d_off (8), d_reclen (2), d_type (1), d_name (NUL terminated).
+// Filesystems that leave d_type as DT_UNKNOWN have isDir decide instead.
+func parseDirents(buf []byte, entries []dirent, isDir func(name string) bool) []dirent {
+	for len(buf) >= 19 {
+		reclen := int(binary.NativeEndian.Uint16(buf[16:18]))
+		if reclen == 0 || reclen > len(buf) {
+			break
+		}
+		typ := buf[18]
+		name := buf[19:reclen]
+		for i, c := range name {
+			if c == 0 {
+				name = name[:i]
+				break
+			}
+		}
+		buf = buf[reclen:]
+
+		if string(name) == "." || string(name) == ".." {
+			continue
+		}
+		entry := dirent{name: string(name), isDir: typ == unix.DT_DIR}
+		if typ == unix.DT_UNKNOWN {
+			entry.isDir = isDir(entry.name)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// stat returns the attributes of an entry without following symlinks
+func (d *fdDir) stat(name string) (os.FileInfo, error) {
+	var st unix.Stat_t
+	if err := unix.Fstatat(d.fd, name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return nil, &os.PathError{Op: "fstatat", Path: name, Err: err}
+	}
+	return &statInfo{name: name, st: st}, nil
+}
+
+// open opens a subdirectory relative to this one
+func (d *fdDir) open(name string) (dirHandle, error) {
+	fd, err := unix.Openat(d.fd, name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: name, Err: err}
+	}
+	return &fdDir{fd: fd}, nil
+}
+
+// remove unlinks an entry, using AT_REMOVEDIR for directories
+func (d *fdDir) remove(name string, isDir bool) error {
+	flags := 0
+	if isDir {
+		flags = unix.AT_REMOVEDIR
+	}
+	if err := unix.Unlinkat(d.fd, name, flags); err != nil {
+		return &os.PathError{Op: "unlinkat", Path: name, Err: err}
+	}
+	return nil
+}
+
+// close releases the directory file descriptor
+func (d *fdDir) close() error {
+	return unix.Close(d.fd)
+}
+
+// statInfo adapts a unix.Stat_t to os.FileInfo for the filters
+type statInfo struct {
+	name string
+	st   unix.Stat_t
+}
+
+func (s *statInfo) Name() string { return s.name }
+func (s *statInfo) Size() int64  { return s.st.Size }
+func (s *statInfo) Sys() any     { return &s.st }
+
+func (s *statInfo) ModTime() time.Time {
+	return time.Unix(int64(s.st.Mtim.Sec), int64(s.st.Mtim.Nsec)) //nolint:unconvert // Field widths differ between architectures
+}
+
+func (s *statInfo) IsDir() bool { return s.st.Mode&unix.S_IFMT == unix.S_IFDIR }
+
+func (s *statInfo) Mode() os.FileMode {
+	mode := os.FileMode(s.st.Mode & 0777)
+	switch s.st.Mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+		mode |= os.ModeDir
+	case unix.S_IFLNK:
+		mode |= os.ModeSymlink
+	case unix.S_IFIFO:
+		mode |= os.ModeNamedPipe
+	case unix.S_IFSOCK:
+		mode |= os.ModeSocket
+	case unix.S_IFCHR:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case unix.S_IFBLK:
+		mode |= os.ModeDevice
+	}
+	if s.st.Mode&unix.S_ISUID != 0 {
+		mode |= os.ModeSetuid
+	}
+	if s.st.Mode&unix.S_ISGID != 0 {
+		mode |= os.ModeSetgid
+	}
+	if s.st.Mode&unix.S_ISVTX != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
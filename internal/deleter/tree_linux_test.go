@@ -0,0 +1,50 @@
+//go:build linux
+
+package deleter
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// direntRecord encodes a linux_dirent64 record
+func direntRecord(name string, typ byte) []byte {
+	reclen := (19 + len(name) + 1 + 7) &^ 7
+	rec := make([]byte, reclen)
+	binary.NativeEndian.PutUint64(rec[0:8], 1)
+	binary.NativeEndian.PutUint16(rec[16:18], uint16(reclen))
+	rec[18] = typ
+	copy(rec[19:], name)
+	return rec
+}
+
+func TestParseDirentsUnknownType(t *testing.T) {
+	var buf []byte
+	buf = append(buf, direntRecord(".", unix.DT_DIR)...)
+	buf = append(buf, direntRecord("dir", unix.DT_DIR)...)
+	buf = append(buf, direntRecord("file", unix.DT_REG)...)
+	buf = append(buf, direntRecord("untyped-dir", unix.DT_UNKNOWN)...)
+	buf = append(buf, direntRecord("untyped-file", unix.DT_UNKNOWN)...)
+
+	var asked []string
+	got := parseDirents(buf, nil, func(name string) bool {
+		asked = append(asked, name)
+		return name == "untyped-dir"
+	})
+
+	want := []dirent{
+		{name: "dir", isDir: true},
+		{name: "file"},
+		{name: "untyped-dir", isDir: true},
+		{name: "untyped-file"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDirents = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(asked, []string{"untyped-dir", "untyped-file"}) {
+		t.Errorf("expected only untyped entries to be looked up, got %v", asked)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !linux
+
+package deleter
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// pathDir is a directory handled through full paths on platforms without
+// a getdents/unlinkat fast path
+type pathDir struct {
+	path string
+}
+
+// openRoot opens the root of a tree
+func openRoot(path string) (dirHandle, error) {
+	return &pathDir{path: path}, nil
+}
+
+// entries reads all entries of the directory
+func (d *pathDir) entries() ([]dirent, error) {
+	list, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]dirent, 0, len(list))
+	for _, e := range list {
+		entries = append(entries, dirent{name: e.Name(), isDir: e.IsDir()})
+	}
+	return entries, nil
+}
+
+// stat returns the attributes of an entry without following symlinks
+func (d *pathDir) stat(name string) (os.FileInfo, error) {
+	return os.Lstat(filepath.Join(d.path, name))
+}
+
+// open opens a subdirectory
+func (d *pathDir) open(name string) (dirHandle, error) {
+	return &pathDir{path: filepath.Join(d.path, name)}, nil
+}
+
+// remove removes an entry
+func (d *pathDir) remove(name string, _ bool) error {
+	return os.Remove(filepath.Join(d.path, name))
+}
+
+// close is a no-op for path based directories
+func (d *pathDir) close() error {
+	return nil
+}
@@ -0,0 +1,264 @@
+// Package dupes finds files with identical content for nuke
+package dupes
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"nuke/internal/scanner"
+)
+
+// partialSize is how much of each file is hashed before reading it whole
+const partialSize = 4096
+
+// Group is a set of files with identical content
+type Group struct {
+	Size  int64              // Size of each file
+	Files []scanner.FileInfo // Files in the group, sorted by path
+}
+
+// Reclaimable returns the space freed by keeping a single file
+func (g Group) Reclaimable() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// HashError describes a file that could not be read while hashing
+type HashError struct {
+	Path string
+	Err  error
+}
+
+// Find groups the regular files that have identical content: by size
+// first, then by a hash of their first block, then by a hash of all of
+// it. Hashing runs on the given number of workers. Hard links to the same
+// file are the same content rather than duplicates, so only one of them
+// is considered. Empty files are ignored.
+func Find(files []scanner.FileInfo, workers int, onError func(HashError)) []Group {
+	if workers <= 0 {
+		workers = 8
+	}
+
+	// Group by size. A file reached twice, through overlapping targets or
+	// another hard link, must never count as its own duplicate.
+	bySize := make(map[int64][]scanner.FileInfo)
+	seenPaths := make(map[string]bool)
+	seenInodes := make(map[[2]uint64]bool)
+	for _, f := range files {
+		if !f.Mode.IsRegular() || f.Size == 0 || seenPaths[f.Path] {
+			continue
+		}
+		seenPaths[f.Path] = true
+		if f.Inode != 0 {
+			id := [2]uint64{f.Dev, f.Inode}
+			if seenInodes[id] {
+				continue
+			}
+			seenInodes[id] = true
+		}
+		bySize[f.Size] = append(bySize[f.Size], f)
+	}
+
+	var candidates [][]scanner.FileInfo
+	for _, same := range bySize {
+		if len(same) > 1 {
+			candidates = append(candidates, same)
+		}
+	}
+
+	// Narrow down by the first block, then by the whole content. Files
+	// no larger than a block are fully hashed by the first pass.
+	candidates = refine(candidates, workers, onError, func(f scanner.FileInfo) (string, error) {
+		return hashFile(f.Path, partialSize)
+	})
+	candidates = refine(candidates, workers, onError, func(f scanner.FileInfo) (string, error) {
+		if f.Size <= partialSize {
+			return "", nil
+		}
+		return hashFile(f.Path, -1)
+	})
+
+	groups := make([]Group, 0, len(candidates))
+	for _, same := range candidates {
+		sort.Slice(same, func(i, j int) bool { return same[i].Path < same[j].Path })
+		groups = append(groups, Group{Size: same[0].Size, Files: same})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Reclaimable() != groups[j].Reclaimable() {
+			return groups[i].Reclaimable() > groups[j].Reclaimable()
+		}
+		return groups[i].Files[0].Path < groups[j].Files[0].Path
+	})
+	return groups
+}
+
+// refine splits each candidate set by a key computed in parallel, keeping
+// the subsets with more than one file. Files that cannot be read are
+// reported and dropped.
+func refine(sets [][]scanner.FileInfo, workers int, onError func(HashError), key func(scanner.FileInfo) (string, error)) [][]scanner.FileInfo {
+	type job struct {
+		set, index int
+	}
+	keys := make([][]string, len(sets))
+	failed := make([][]bool, len(sets))
+	jobs := make(chan job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				f := sets[j.set][j.index]
+				k, err := key(f)
+				if err != nil {
+					failed[j.set][j.index] = true
+					if onError != nil {
+						mu.Lock()
+						onError(HashError{Path: f.Path, Err: err})
+						mu.Unlock()
+					}
+					continue
+				}
+				keys[j.set][j.index] = k
+			}
+		}()
+	}
+	for s, set := range sets {
+		keys[s] = make([]string, len(set))
+		failed[s] = make([]bool, len(set))
+	}
+	for s, set := range sets {
+		for i := range set {
+			jobs <- job{s, i}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var refined [][]scanner.FileInfo
+	for s, set := range sets {
+		byKey := make(map[string][]scanner.FileInfo)
+		var order []string
+		for i, f := range set {
+			if failed[s][i] {
+				continue
+			}
+			if _, ok := byKey[keys[s][i]]; !ok {
+				order = append(order, keys[s][i])
+			}
+			byKey[keys[s][i]] = append(byKey[keys[s][i]], f)
+		}
+		for _, k := range order {
+			if len(byKey[k]) > 1 {
+				refined = append(refined, byKey[k])
+			}
+		}
+	}
+	return refined
+}
+
+// hashFile hashes the first limit bytes of a file, or all of it if limit
+// is negative
+func hashFile(path string, limit int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	var r io.Reader = file
+	if limit >= 0 {
+		r = io.LimitReader(file, limit)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return string(h.Sum(nil)), nil
+}
+
+// Unchanged reports whether the files still have the size, modification
+// time and identity they had when they were compared, so a group is only
+// acted on while its contents are known to be identical
+func Unchanged(files ...scanner.FileInfo) bool {
+	for _, f := range files {
+		now, err := scanner.Stat(f.Path)
+		if err != nil || !now.Mode.IsRegular() || now.Size != f.Size || now.ModTime != f.ModTime {
+			return false
+		}
+		if f.Inode != 0 && (now.Dev != f.Dev || now.Inode != f.Inode) {
+			return false
+		}
+	}
+	return true
+}
+
+// Policy decides which file of a group is kept
+type Policy string
+
+// Keep policies
+const (
+	KeepOldest   Policy = "oldest"   // Keep the file modified first
+	KeepNewest   Policy = "newest"   // Keep the file modified last
+	KeepShortest Policy = "shortest" // Keep the file with the shortest path
+)
+
+// ParsePolicy parses a keep policy name
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case KeepOldest, KeepNewest, KeepShortest:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown keep policy %q (use oldest, newest or shortest)", s)
+}
+
+// Keeper chooses the file to keep in each group
+type Keeper struct {
+	Policy   Policy   // Tie-breaker within the preferred directories
+	Priority []string // Directories whose files are kept first, in order
+}
+
+// Split returns the file of a group to keep and the ones to remove
+func (k Keeper) Split(g Group) (keep scanner.FileInfo, remove []scanner.FileInfo) {
+	files := append([]scanner.FileInfo(nil), g.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if pa, pb := k.rank(a.Path), k.rank(b.Path); pa != pb {
+			return pa < pb
+		}
+		switch k.Policy {
+		case KeepNewest:
+			if a.ModTime != b.ModTime {
+				return a.ModTime > b.ModTime
+			}
+		case KeepShortest:
+			if len(a.Path) != len(b.Path) {
+				return len(a.Path) < len(b.Path)
+			}
+		default:
+			if a.ModTime != b.ModTime {
+				return a.ModTime < b.ModTime
+			}
+		}
+		return a.Path < b.Path
+	})
+	return files[0], files[1:]
+}
+
+// rank returns the position of the first priority directory containing
+// path, or the number of directories if none does
+func (k Keeper) rank(path string) int {
+	for i, dir := range k.Priority {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return i
+		}
+	}
+	return len(k.Priority)
+}
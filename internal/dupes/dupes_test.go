@@ -0,0 +1,141 @@
+package dupes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nuke/internal/scanner"
+)
+
+func TestFind(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-dupes-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// Same size and first block, different tail
+	block := strings.Repeat("x", partialSize)
+	files := map[string]string{
+		"a/one.txt":  "same content",
+		"b/two.txt":  "same content",
+		"b/other":    "diff content",
+		"a/big1":     block + "tail",
+		"b/big2":     block + "tail",
+		"b/big3":     block + "TAIL",
+		"a/empty1":   "",
+		"b/empty2":   "",
+		"a/unique":   "only once",
+		"b/c/three1": "same content",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	if err := os.Link(filepath.Join(tmpDir, "a", "unique"), filepath.Join(tmpDir, "a", "unique.link")); err != nil {
+		t.Fatalf("failed to create hard link: %v", err)
+	}
+
+	var scanned []scanner.FileInfo
+	err = scanner.ScanWithCallback(context.Background(), tmpDir, scanner.Options{Recursive: true}, func(f scanner.FileInfo) {
+		scanned = append(scanned, f)
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// Scanning twice must not make files duplicates of themselves
+	groups := Find(append(scanned, scanned...), 4, nil)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %v", len(groups), groups)
+	}
+
+	// Largest reclaimable space first
+	var got [][]string
+	for _, g := range groups {
+		var names []string
+		for _, f := range g.Files {
+			rel, _ := filepath.Rel(tmpDir, f.Path)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		got = append(got, names)
+	}
+	want := [][]string{
+		{"a/big1", "b/big2"},
+		{"a/one.txt", "b/c/three1", "b/two.txt"},
+	}
+	for i := range want {
+		if strings.Join(got[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("group %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if r := groups[1].Reclaimable(); r != 2*int64(len("same content")) {
+		t.Errorf("reclaimable = %d, want %d", r, 2*len("same content"))
+	}
+
+	// A group is only trusted while its files stay as they were compared
+	if !Unchanged(groups[1].Files...) {
+		t.Error("expected untouched files to be unchanged")
+	}
+	changed := groups[1].Files[0]
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(changed.Path, later, later); err != nil {
+		t.Fatalf("failed to set times: %v", err)
+	}
+	if Unchanged(groups[1].Files...) {
+		t.Error("expected a modified file to be reported as changed")
+	}
+	if err := os.Remove(groups[0].Files[0].Path); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	if Unchanged(groups[0].Files...) {
+		t.Error("expected a removed file to be reported as changed")
+	}
+}
+
+func TestKeeperSplit(t *testing.T) {
+	now := time.Now().Unix()
+	g := Group{Size: 1, Files: []scanner.FileInfo{
+		{Path: "/data/archive/deep/photo.jpg", ModTime: now - 300},
+		{Path: "/data/photo.jpg", ModTime: now},
+		{Path: "/home/me/photo.jpg", ModTime: now - 100},
+	}}
+
+	tests := []struct {
+		keeper Keeper
+		want   string
+	}{
+		{Keeper{Policy: KeepOldest}, "/data/archive/deep/photo.jpg"},
+		{Keeper{Policy: KeepNewest}, "/data/photo.jpg"},
+		{Keeper{Policy: KeepShortest}, "/data/photo.jpg"},
+		{Keeper{Policy: KeepOldest, Priority: []string{"/home", "/data"}}, "/home/me/photo.jpg"},
+		{Keeper{Policy: KeepNewest, Priority: []string{"/nowhere", "/data"}}, "/data/photo.jpg"},
+	}
+	for i, tt := range tests {
+		keep, remove := tt.keeper.Split(g)
+		if keep.Path != tt.want {
+			t.Errorf("case %d: kept %s, want %s", i, keep.Path, tt.want)
+		}
+		if len(remove) != 2 {
+			t.Errorf("case %d: removing %d files, want 2", i, len(remove))
+		}
+		for _, f := range remove {
+			if f.Path == keep.Path {
+				t.Errorf("case %d: kept file is also removed", i)
+			}
+		}
+	}
+
+	if _, err := ParsePolicy("largest"); err == nil {
+		t.Error("ParsePolicy accepted an unknown policy")
+	}
+}
@@ -0,0 +1,205 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Entry types matched by Options.Types
+const (
+	TypeFile          = "file"
+	TypeDir           = "dir"
+	TypeSymlink       = "symlink"
+	TypeBrokenSymlink = "broken-symlink"
+	TypeSocket        = "socket"
+	TypeFifo          = "fifo"
+	TypeDevice        = "device"
+)
+
+// ParseTypes parses a comma separated list of entry types
+func ParseTypes(s string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		switch t = strings.TrimSpace(t); t {
+		case TypeFile, TypeDir, TypeSymlink, TypeBrokenSymlink, TypeSocket, TypeFifo, TypeDevice:
+			types = append(types, t)
+		case "f":
+			types = append(types, TypeFile)
+		case "d":
+			types = append(types, TypeDir)
+		case "l":
+			types = append(types, TypeSymlink)
+		default:
+			return nil, fmt.Errorf("unknown type %q (use file, dir, symlink, broken-symlink, socket, fifo or device)", t)
+		}
+	}
+	return types, nil
+}
+
+// entryType returns the type of an entry. Broken symlinks are told apart
+// from working ones by following them.
+func entryType(path string, info os.FileInfo) string {
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		return TypeFile
+	case mode.IsDir():
+		return TypeDir
+	case mode&os.ModeSymlink != 0:
+		if _, err := os.Stat(path); err != nil {
+			return TypeBrokenSymlink
+		}
+		return TypeSymlink
+	case mode&os.ModeSocket != 0:
+		return TypeSocket
+	case mode&os.ModeNamedPipe != 0:
+		return TypeFifo
+	default:
+		return TypeDevice
+	}
+}
+
+// matchType reports whether an entry has one of the types. A broken
+// symlink is a symlink as well.
+func matchType(types []string, path string, info os.FileInfo) bool {
+	t := entryType(path, info)
+	for _, want := range types {
+		if want == t || (want == TypeSymlink && t == TypeBrokenSymlink) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePerm parses a permission filter in octal, like find -perm: "644"
+// matches exactly, "-644" requires all of the bits and "/022" any of them
+func ParsePerm(s string) (bits uint32, op string, err error) {
+	op = "="
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "/") {
+		op, s = s[:1], s[1:]
+	}
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 07777 {
+		return 0, "", fmt.Errorf("invalid permission bits %q (use octal, e.g. 644, -200 or /022)", s)
+	}
+	return uint32(n), op, nil
+}
+
+// permBits returns the permission bits of a mode in Unix octal form,
+// including setuid, setgid and sticky
+func permBits(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
+
+// matchPerm applies a permission filter to a mode
+func matchPerm(bits uint32, op string, mode os.FileMode) bool {
+	have := permBits(mode)
+	switch op {
+	case "-":
+		return have&bits == bits
+	case "/":
+		return have&bits != 0
+	default:
+		return have == bits
+	}
+}
+
+// isEmpty reports whether an entry is an empty regular file or an empty
+// directory
+func isEmpty(path string, info os.FileInfo) bool {
+	switch {
+	case info.Mode().IsRegular():
+		return info.Size() == 0
+	case info.IsDir():
+		dir, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer func() { _ = dir.Close() }()
+		names, _ := dir.Readdirnames(1)
+		return len(names) == 0
+	default:
+		return false
+	}
+}
+
+// depthOf returns how far below the scan target root a path is; the root
+// itself is at depth 0
+func depthOf(root, path string) int {
+	rel := relTo(root, path)
+	if rel == "" {
+		return 0
+	}
+	return strings.Count(rel, "/") + 1
+}
+
+// LookupOwner resolves a user name or numeric ID
+func LookupOwner(s string) (uint32, error) {
+	if id, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	u, err := user.Lookup(s)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(u.Uid, 10, 32)
+	return uint32(id), err
+}
+
+// LookupGroup resolves a group name or numeric ID
+func LookupGroup(s string) (uint32, error) {
+	if id, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	g, err := user.LookupGroup(s)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(g.Gid, 10, 32)
+	return uint32(id), err
+}
+
+// names caches user and group names by ID for expressions and explanations
+var names sync.Map
+
+// userName returns the name of a user ID, or the ID if it has none
+func userName(uid uint32) string {
+	key := "u" + strconv.FormatUint(uint64(uid), 10)
+	if name, ok := names.Load(key); ok {
+		return name.(string)
+	}
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	names.Store(key, name)
+	return name
+}
+
+// groupName returns the name of a group ID, or the ID if it has none
+func groupName(gid uint32) string {
+	key := "g" + strconv.FormatUint(uint64(gid), 10)
+	if name, ok := names.Load(key); ok {
+		return name.(string)
+	}
+	name := strconv.FormatUint(uint64(gid), 10)
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	names.Store(key, name)
+	return name
+}
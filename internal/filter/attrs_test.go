@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePerm(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  os.FileMode
+		want  bool
+	}{
+		{"644", 0644, true},
+		{"644", 0755, false},
+		{"-022", 0622, true},
+		{"-022", 0602, false},
+		{"/111", 0744, true},
+		{"/111", 0644, false},
+		{"4755", 0755 | os.ModeSetuid, true},
+	}
+	for _, tt := range tests {
+		bits, op, err := ParsePerm(tt.input)
+		if err != nil {
+			t.Fatalf("ParsePerm(%q) failed: %v", tt.input, err)
+		}
+		if got := matchPerm(bits, op, tt.mode); got != tt.want {
+			t.Errorf("perm %q on %v = %v, want %v", tt.input, tt.mode, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "999", "rwx", "-", "77777"} {
+		if _, _, err := ParsePerm(bad); err == nil {
+			t.Errorf("ParsePerm(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestAttributeFilters(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	if err := os.MkdirAll(filepath.Join(tmpDir, "a", "b"), 0755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tmpDir, "empty"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	for name, content := range map[string]string{"a/file.txt": "data", "a/b/zero": ""} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	if err := os.Symlink("a/file.txt", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("missing", filepath.Join(tmpDir, "dangling")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	types, err := ParseTypes("f,broken-symlink")
+	if err != nil {
+		t.Fatalf("ParseTypes failed: %v", err)
+	}
+	if _, err := ParseTypes("file,pipe"); err == nil {
+		t.Error("ParseTypes accepted an unknown type")
+	}
+
+	tests := []struct {
+		opts Options
+		want []string
+	}{
+		{Options{Types: types}, []string{"a/file.txt", "a/b/zero", "dangling"}},
+		{Options{Types: []string{TypeSymlink}}, []string{"link", "dangling"}},
+		{Options{Types: []string{TypeDir}, Empty: true}, []string{"empty"}},
+		{Options{Empty: true}, []string{"a/b/zero", "empty"}},
+		{Options{MinDepth: 2}, []string{"a/file.txt", "a/b", "a/b/zero"}},
+		{Options{MaxDepth: 1}, []string{"a", "empty", "link", "dangling"}},
+	}
+	paths := []string{"a", "a/file.txt", "a/b", "a/b/zero", "empty", "link", "dangling"}
+	for i, tt := range tests {
+		var got []string
+		for _, p := range paths {
+			path := filepath.Join(tmpDir, filepath.FromSlash(p))
+			info, err := os.Lstat(path)
+			if err != nil {
+				t.Fatalf("failed to stat %s: %v", p, err)
+			}
+			if tt.opts.Match(tmpDir, path, info) {
+				got = append(got, p)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("case %d: matched %v, want %v", i, got, tt.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != tt.want[j] {
+				t.Errorf("case %d: matched %v, want %v", i, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if opts := (Options{MaxDepth: 1}); !opts.Prune(tmpDir, filepath.Join(tmpDir, "a", "b")) || opts.Prune(tmpDir, filepath.Join(tmpDir, "a")) {
+		t.Error("MaxDepth should prune directories below the limit only")
+	}
+}
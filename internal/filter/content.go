@@ -0,0 +1,238 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultContainsMaxSize is the largest file --contains reads by default
+const DefaultContainsMaxSize = 16 << 20
+
+// sniffLen is how much of a file is read to detect its type; enough for
+// the tar header magic at offset 257
+const sniffLen = 512
+
+// magic describes a file type by the bytes at an offset
+type magic struct {
+	offset int
+	sig    string
+	mime   string
+}
+
+// magics are checked in order, so more specific signatures come first
+var magics = []magic{
+	{0, "\x89PNG\r\n\x1a\n", "image/png"},
+	{0, "\xff\xd8\xff", "image/jpeg"},
+	{0, "GIF87a", "image/gif"},
+	{0, "GIF89a", "image/gif"},
+	{0, "BM", "image/bmp"},
+	{0, "II*\x00", "image/tiff"},
+	{0, "MM\x00*", "image/tiff"},
+	{0, "\x00\x00\x01\x00", "image/x-icon"},
+	{0, "8BPS", "image/vnd.adobe.photoshop"},
+	{0, "%PDF-", "application/pdf"},
+	{0, "PK\x03\x04", "application/zip"},
+	{0, "PK\x05\x06", "application/zip"},
+	{0, "\x1f\x8b", "application/gzip"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "\xfd7zXZ\x00", "application/x-xz"},
+	{0, "\x28\xb5\x2f\xfd", "application/zstd"},
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{0, "Rar!\x1a\x07", "application/vnd.rar"},
+	{257, "ustar", "application/x-tar"},
+	{0, "SQLite format 3\x00", "application/vnd.sqlite3"},
+	{0, "\x00asm", "application/wasm"},
+	{0, "MZ", "application/vnd.microsoft.portable-executable"},
+	{0, "\xfe\xed\xfa\xce", "application/x-mach-binary"},
+	{0, "\xfe\xed\xfa\xcf", "application/x-mach-binary"},
+	{0, "\xce\xfa\xed\xfe", "application/x-mach-binary"},
+	{0, "\xcf\xfa\xed\xfe", "application/x-mach-binary"},
+	{0, "\xca\xfe\xba\xbe", "application/java-vm"},
+	{0, "ID3", "audio/mpeg"},
+	{0, "fLaC", "audio/flac"},
+	{0, "OggS", "audio/ogg"},
+	{0, "\x1aE\xdf\xa3", "video/webm"},
+	{0, "#!", "text/x-shellscript"},
+	{0, "%!PS", "application/postscript"},
+}
+
+// ParseMIME parses a comma separated list of MIME type globs such as
+// "image/*"
+func ParseMIME(s string) []string {
+	var globs []string
+	for _, g := range strings.Split(s, ",") {
+		if g = strings.ToLower(strings.TrimSpace(g)); g != "" {
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+// mimeOf detects the type of a regular file from its first bytes. Other
+// entries and unreadable files have no type.
+func mimeOf(path string, info os.FileInfo) string {
+	if !info.Mode().IsRegular() {
+		return ""
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return ""
+	}
+	return sniff(buf[:n])
+}
+
+// sniff returns the MIME type of data, the start of a file
+func sniff(data []byte) string {
+	if len(data) == 0 {
+		return "application/x-empty"
+	}
+	if mime := sniffELF(data); mime != "" {
+		return mime
+	}
+	for _, m := range magics {
+		if len(data) >= m.offset+len(m.sig) && string(data[m.offset:m.offset+len(m.sig)]) == m.sig {
+			return m.mime
+		}
+	}
+
+	// Containers identified by a second signature
+	if len(data) >= 12 {
+		switch {
+		case string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+			return "image/webp"
+		case string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+			return "audio/wav"
+		case string(data[:4]) == "RIFF" && string(data[8:12]) == "AVI ":
+			return "video/x-msvideo"
+		case string(data[4:8]) == "ftyp":
+			switch string(data[8:12]) {
+			case "heic", "heix", "mif1":
+				return "image/heic"
+			case "avif":
+				return "image/avif"
+			case "qt  ":
+				return "video/quicktime"
+			}
+			return "video/mp4"
+		}
+	}
+
+	if isText(data) {
+		return "text/plain"
+	}
+	return "application/octet-stream"
+}
+
+// sniffELF tells core dumps apart from other ELF files by the object type
+// in the header
+func sniffELF(data []byte) string {
+	if len(data) < 18 || string(data[:4]) != "\x7fELF" {
+		return ""
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[5] == 2 {
+		order = binary.BigEndian
+	}
+	switch order.Uint16(data[16:18]) {
+	case 4: // ET_CORE
+		return "application/x-coredump"
+	case 3: // ET_DYN
+		if hasInterp(data, order) {
+			return "application/x-pie-executable"
+		}
+		return "application/x-sharedlib"
+	case 1: // ET_REL
+		return "application/x-object"
+	}
+	return "application/x-executable"
+}
+
+// hasInterp reports whether the program headers within data request an
+// interpreter, which tells position independent executables apart from
+// shared libraries
+func hasInterp(data []byte, order binary.ByteOrder) bool {
+	var phoff uint64
+	var phentsize, phnum uint16
+	if data[4] == 2 { // 64-bit
+		if len(data) < 64 {
+			return false
+		}
+		phoff = order.Uint64(data[32:40])
+		phentsize, phnum = order.Uint16(data[54:56]), order.Uint16(data[56:58])
+	} else {
+		if len(data) < 52 {
+			return false
+		}
+		phoff = uint64(order.Uint32(data[28:32]))
+		phentsize, phnum = order.Uint16(data[42:44]), order.Uint16(data[44:46])
+	}
+	if phoff >= uint64(len(data)) {
+		return false
+	}
+	for i := uint64(0); i < uint64(phnum); i++ {
+		off := phoff + i*uint64(phentsize)
+		if off+4 > uint64(len(data)) {
+			break
+		}
+		if order.Uint32(data[off:off+4]) == 3 { // PT_INTERP
+			return true
+		}
+	}
+	return false
+}
+
+// isText reports whether data looks like text: valid UTF-8 without NUL
+// bytes, allowing a character cut off at the end
+func isText(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return false
+	}
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return utf8.Valid(data)
+}
+
+// matchMIME reports whether a type matches one of the globs. A glob
+// without a slash matches the subtype, so "x-coredump" is enough.
+func matchMIME(globs []string, mime string) bool {
+	if mime == "" {
+		return false
+	}
+	_, subtype, _ := strings.Cut(mime, "/")
+	for _, g := range globs {
+		if globEqual(g, mime) || (!strings.Contains(g, "/") && globEqual(g, subtype)) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether a regular file no larger than maxSize has
+// content matching re, and returns the first match
+func contains(re *regexp.Regexp, maxSize int64, path string, info os.FileInfo) (bool, string) {
+	if !info.Mode().IsRegular() || (maxSize > 0 && info.Size() > maxSize) {
+		return false, ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, ""
+	}
+	loc := re.FindIndex(data)
+	if loc == nil {
+		return false, ""
+	}
+	return true, string(data[loc[0]:loc[1]])
+}
@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	elfCore := append([]byte("\x7fELF\x02\x01\x01"), make([]byte, 9)...)
+	elfCore = append(elfCore, 4, 0)
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte("\x89PNG\r\n\x1a\n...."), "image/png"},
+		{[]byte("\xff\xd8\xff\xe0"), "image/jpeg"},
+		{[]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{[]byte("%PDF-1.7"), "application/pdf"},
+		{elfCore, "application/x-coredump"},
+		{tar, "application/x-tar"},
+		{[]byte("#!/bin/sh\necho hi\n"), "text/x-shellscript"},
+		{[]byte("héllo wörld\n"), "text/plain"},
+		{[]byte{0, 1, 2, 3}, "application/octet-stream"},
+		{nil, "application/x-empty"},
+	}
+	for _, tt := range tests {
+		if got := sniff(tt.data); got != tt.want {
+			t.Errorf("sniff(%q) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+
+	if !matchMIME(ParseMIME("image/*, x-coredump"), "application/x-coredump") {
+		t.Error("subtype glob did not match")
+	}
+	if matchMIME(ParseMIME("image/*"), "text/plain") {
+		t.Error("image/* matched text/plain")
+	}
+}
+
+func TestContentFilters(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"leak.env":  "API_TOKEN=sk-12345\n",
+		"clean.env": "API_TOKEN=\n",
+		"image.dat": "GIF89a....",
+		"big.txt":   "padding padding padding sk-99999",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		opts Options
+		want map[string]bool
+	}{
+		{Options{MIME: []string{"image/*"}}, map[string]bool{"image.dat": true}},
+		{Options{Contains: regexp.MustCompile(`sk-\d+`), ContainsMaxSize: 24}, map[string]bool{"leak.env": true}},
+		{Options{Contains: regexp.MustCompile(`sk-\d+`)}, map[string]bool{"leak.env": true, "big.txt": true}},
+	}
+	for i, tt := range tests {
+		for name := range files {
+			path := filepath.Join(tmpDir, name)
+			info, err := os.Lstat(path)
+			if err != nil {
+				t.Fatalf("failed to stat %s: %v", name, err)
+			}
+			if got := tt.opts.Match(tmpDir, path, info); got != tt.want[name] {
+				t.Errorf("case %d: Match(%s) = %v, want %v", i, name, got, tt.want[name])
+			}
+		}
+	}
+}
@@ -0,0 +1,557 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nuke/internal/utils"
+)
+
+// Expr is a boolean filter expression, e.g.
+//
+//	ext in [log,tmp] and (mtime > 7d or size > 1G) and not path ~ "archive/"
+//
+// Comparisons combine with and, or, not and parentheses. String fields
+// support =, != (with * and ? wildcards), ~, !~ (regular expressions) and
+// in [...]; numeric fields support =, !=, <, <=, > and >=. Times compare by
+// age, so mtime > 7d means modified more than 7 days ago.
+type Expr struct {
+	src  string
+	root node
+}
+
+// ParseExpr parses a filter expression. Errors are *ParseError.
+func ParseExpr(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok.pos, "unexpected %s, expected and, or or the end", tok)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the expression as it was written
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval reports whether a file beneath the scan target root matches
+func (e *Expr) Eval(root, path string, info os.FileInfo) bool {
+	return e.root.eval(newAttrs(root, path, info))
+}
+
+// Explain evaluates the expression and describes the comparisons that
+// decided the result, with the values they saw
+func (e *Expr) Explain(root, path string, info os.FileInfo) (bool, string) {
+	a := newAttrs(root, path, info)
+	return e.root.eval(a), e.root.explain(a)
+}
+
+// ParseError describes an invalid filter expression
+type ParseError struct {
+	Expr string // The expression
+	Pos  int    // Byte offset of the problem
+	Msg  string
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Pos+1)
+}
+
+// Context returns the expression with a marker under the problem
+func (e *ParseError) Context() string {
+	return e.Expr + "\n" + strings.Repeat(" ", e.Pos) + "^"
+}
+
+// attrs holds what comparisons look at for one file
+type attrs struct {
+	root string
+	path string
+	info os.FileInfo
+	now  time.Time
+}
+
+// newAttrs prepares a file for evaluation
+func newAttrs(root, path string, info os.FileInfo) *attrs {
+	return &attrs{root: root, path: path, info: info, now: time.Now()}
+}
+
+// fieldKind determines the operators and values a field takes
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindSize
+	kindAge
+	kindNumber
+)
+
+// field is something about a file an expression can compare
+type field struct {
+	kind fieldKind
+	str  func(a *attrs) string // For kindString
+	num  func(a *attrs) int64  // For the numeric kinds; ages in nanoseconds
+}
+
+// fields are the names usable in expressions
+var fields = map[string]field{
+	"name": {kind: kindString, str: func(a *attrs) string { return filepath.Base(a.path) }},
+	"path": {kind: kindString, str: func(a *attrs) string {
+		if rel := relTo(a.root, a.path); rel != "" {
+			return rel
+		}
+		return filepath.Base(a.path)
+	}},
+	"ext": {kind: kindString, str: func(a *attrs) string {
+		return strings.TrimPrefix(filepath.Ext(a.path), ".")
+	}},
+	"type": {kind: kindString, str: func(a *attrs) string { return entryType(a.path, a.info) }},
+	"mime": {kind: kindString, str: func(a *attrs) string { return mimeOf(a.path, a.info) }},
+	"owner": {kind: kindString, str: func(a *attrs) string {
+		uid, _, _ := ownerOf(a.info)
+		return userName(uid)
+	}},
+	"group": {kind: kindString, str: func(a *attrs) string {
+		_, gid, _ := ownerOf(a.info)
+		return groupName(gid)
+	}},
+	"size":  {kind: kindSize, num: func(a *attrs) int64 { return a.info.Size() }},
+	"mtime": {kind: kindAge, num: func(a *attrs) int64 { return int64(a.now.Sub(a.info.ModTime())) }},
+	"atime": {kind: kindAge, num: func(a *attrs) int64 { return int64(a.now.Sub(accessTime(a.info))) }},
+	"ctime": {kind: kindAge, num: func(a *attrs) int64 { return int64(a.now.Sub(changeTime(a.info))) }},
+	"depth": {kind: kindNumber, num: func(a *attrs) int64 { return int64(depthOf(a.root, a.path)) }},
+}
+
+// fieldNames lists the known fields for error messages
+func fieldNames() string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// format renders a field value for explanations
+func (f field) format(a *attrs) string {
+	switch f.kind {
+	case kindString:
+		return strconv.Quote(f.str(a))
+	case kindSize:
+		return utils.FormatSize(f.num(a))
+	case kindAge:
+		return formatAge(time.Duration(f.num(a)))
+	default:
+		return strconv.FormatInt(f.num(a), 10)
+	}
+}
+
+// formatAge renders an age in the largest whole unit
+func formatAge(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d >= day:
+		return fmt.Sprintf("%dd old", d/day)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh old", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm old", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds old", d/time.Second)
+	}
+}
+
+// node is a part of a parsed expression
+type node interface {
+	eval(a *attrs) bool
+	explain(a *attrs) string
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n andNode) eval(a *attrs) bool { return n.left.eval(a) && n.right.eval(a) }
+func (n orNode) eval(a *attrs) bool  { return n.left.eval(a) || n.right.eval(a) }
+func (n notNode) eval(a *attrs) bool { return !n.inner.eval(a) }
+
+// explain gives both sides of a match, or the side that failed
+func (n andNode) explain(a *attrs) string {
+	switch {
+	case !n.left.eval(a):
+		return n.left.explain(a)
+	case !n.right.eval(a):
+		return n.right.explain(a)
+	default:
+		return n.left.explain(a) + " and " + n.right.explain(a)
+	}
+}
+
+// explain gives the side that matched, or both sides of a failure
+func (n orNode) explain(a *attrs) string {
+	switch {
+	case n.left.eval(a):
+		return n.left.explain(a)
+	case n.right.eval(a):
+		return n.right.explain(a)
+	default:
+		return "neither " + n.left.explain(a) + " nor " + n.right.explain(a)
+	}
+}
+
+func (n notNode) explain(a *attrs) string {
+	return "not (" + n.inner.explain(a) + ")"
+}
+
+// comparison compares a field with one or more values
+type comparison struct {
+	text   string // Source text of the comparison
+	name   string
+	field  field
+	op     string
+	strs   []string       // Values of string comparisons
+	re     *regexp.Regexp // Compiled value of ~ and !~
+	number int64          // Value of numeric comparisons
+}
+
+func (c *comparison) eval(a *attrs) bool {
+	if c.field.kind == kindString {
+		value := c.field.str(a)
+		switch c.op {
+		case "~":
+			return c.re.MatchString(value)
+		case "!~":
+			return !c.re.MatchString(value)
+		case "!=":
+			return !globEqual(c.strs[0], value)
+		default: // =, in
+			for _, s := range c.strs {
+				if globEqual(s, value) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	value := c.field.num(a)
+	switch c.op {
+	case "!=":
+		return value != c.number
+	case "<":
+		return value < c.number
+	case "<=":
+		return value <= c.number
+	case ">":
+		return value > c.number
+	case ">=":
+		return value >= c.number
+	default:
+		return value == c.number
+	}
+}
+
+func (c *comparison) explain(a *attrs) string {
+	return fmt.Sprintf("%s (%s is %s)", c.text, c.name, c.field.format(a))
+}
+
+// globEqual compares a value with a pattern that may contain * and ?
+func globEqual(pattern, value string) bool {
+	if matched, err := path.Match(pattern, value); err == nil {
+		return matched
+	}
+	return pattern == value
+}
+
+// tokenKind classifies tokens
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+// token is a lexical element of an expression
+type token struct {
+	kind tokenKind
+	text string // Unquoted for strings
+	pos  int
+}
+
+// String describes a token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lex splits an expression into tokens
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == ',':
+			kind := map[byte]tokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, ',': tokComma}[c]
+			tokens = append(tokens, token{kind: kind, text: string(c), pos: i})
+			i++
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, &ParseError{Expr: src, Pos: i, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokString, text: b.String(), pos: i})
+			i = j + 1
+		case strings.IndexByte("=!<>~", c) >= 0:
+			op := string(c)
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "==", "!=", "!~", "<=", ">=":
+					op = two
+				}
+			}
+			if op == "!" {
+				return nil, &ParseError{Expr: src, Pos: i, Msg: `unexpected "!", use "not" or "!="`}
+			}
+			text := op
+			if op == "==" {
+				text = "="
+			}
+			tokens = append(tokens, token{kind: tokOp, text: text, pos: i})
+			i += len(op)
+		default:
+			j := i
+			for j < len(src) && strings.IndexByte(" \t\n()[],\"'=!<>~", src[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokWord, text: src[i:j], pos: i})
+			i = j
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// parser is a recursive descent parser over the tokens of an expression
+type parser struct {
+	src    string
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given keyword, consuming it
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	if tok.kind == tokWord && strings.EqualFold(tok.text, word) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Expr: p.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseOr parses: and-expression { "or" and-expression }
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary { "and" unary }
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// parseUnary parses: "not" unary | "(" or-expression ")" | comparison
+func (p *parser) parseUnary() (node, error) {
+	if p.keyword("not") {
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	if tok := p.peek(); tok.kind == tokLParen {
+		p.take()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.take(); tok.kind != tokRParen {
+			return nil, p.errorf(tok.pos, "expected \")\", got %s", tok)
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses: field operator value | field "in" "[" values "]"
+func (p *parser) parseComparison() (node, error) {
+	start := p.peek().pos
+	tok := p.take()
+	if tok.kind != tokWord {
+		return nil, p.errorf(tok.pos, "expected a field name, got %s", tok)
+	}
+	name := strings.ToLower(tok.text)
+	f, ok := fields[name]
+	if !ok {
+		return nil, p.errorf(tok.pos, "unknown field %q (known fields: %s)", tok.text, fieldNames())
+	}
+	c := &comparison{name: name, field: f}
+
+	opTok := p.peek()
+	switch {
+	case p.keyword("in"):
+		c.op = "in"
+	case opTok.kind == tokOp:
+		p.take()
+		c.op = opTok.text
+	default:
+		return nil, p.errorf(opTok.pos, "expected an operator after %s, got %s", name, opTok)
+	}
+
+	stringOp := c.op == "~" || c.op == "!~" || c.op == "in"
+	orderOp := c.op == "<" || c.op == "<=" || c.op == ">" || c.op == ">="
+	if (f.kind == kindString && orderOp) || (f.kind != kindString && stringOp) {
+		return nil, p.errorf(opTok.pos, "operator %q cannot be used with %s", c.op, name)
+	}
+
+	var values []token
+	if c.op == "in" {
+		if tok := p.take(); tok.kind != tokLBracket {
+			return nil, p.errorf(tok.pos, "expected \"[\" after in, got %s", tok)
+		}
+		for {
+			tok := p.take()
+			if tok.kind != tokWord && tok.kind != tokString {
+				return nil, p.errorf(tok.pos, "expected a value, got %s", tok)
+			}
+			values = append(values, tok)
+			if sep := p.take(); sep.kind == tokRBracket {
+				break
+			} else if sep.kind != tokComma {
+				return nil, p.errorf(sep.pos, "expected \",\" or \"]\", got %s", sep)
+			}
+		}
+	} else {
+		tok := p.take()
+		if tok.kind != tokWord && tok.kind != tokString {
+			return nil, p.errorf(tok.pos, "expected a value after %s, got %s", c.op, tok)
+		}
+		values = append(values, tok)
+	}
+
+	if err := p.setValues(c, values); err != nil {
+		return nil, err
+	}
+	c.text = strings.TrimSpace(p.src[start:p.peek().pos])
+	return c, nil
+}
+
+// setValues converts the value tokens of a comparison for its field
+func (p *parser) setValues(c *comparison, values []token) error {
+	for _, v := range values {
+		c.strs = append(c.strs, v.text)
+	}
+	v := values[0]
+
+	switch c.field.kind {
+	case kindString:
+		if c.op == "~" || c.op == "!~" {
+			re, err := regexp.Compile(v.text)
+			if err != nil {
+				return p.errorf(v.pos, "invalid regular expression: %v", err)
+			}
+			c.re = re
+		}
+	case kindSize:
+		size, err := utils.ParseSize(v.text)
+		if err != nil {
+			return p.errorf(v.pos, "invalid size %q for %s", v.text, c.name)
+		}
+		c.number = size
+	case kindAge:
+		age, err := utils.ParseDuration(v.text)
+		if err != nil {
+			return p.errorf(v.pos, "invalid duration %q for %s", v.text, c.name)
+		}
+		c.number = int64(age)
+	case kindNumber:
+		n, err := strconv.ParseInt(v.text, 10, 64)
+		if err != nil {
+			return p.errorf(v.pos, "invalid number %q for %s", v.text, c.name)
+		}
+		c.number = n
+	}
+	return nil
+}
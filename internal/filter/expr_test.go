@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeInfo is an os.FileInfo with fixed values
+type fakeInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (f fakeInfo) Name() string       { return f.name }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) Mode() os.FileMode  { return f.mode }
+func (f fakeInfo) ModTime() time.Time { return f.modTime }
+func (f fakeInfo) IsDir() bool        { return f.mode.IsDir() }
+func (f fakeInfo) Sys() interface{}   { return nil }
+
+func TestExprEval(t *testing.T) {
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	files := map[string]fakeInfo{
+		"/r/old.log":         {size: 10, modTime: old},
+		"/r/new.log":         {size: 10, modTime: time.Now()},
+		"/r/big.tmp":         {size: 2 << 30, modTime: time.Now()},
+		"/r/archive/old.log": {size: 10, modTime: old},
+		"/r/keep.txt":        {size: 10, modTime: old},
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`ext in [log,tmp] and (mtime > 7d or size > 1G) and not path ~ "archive/"`, []string{"/r/old.log", "/r/big.tmp"}},
+		{`name = "*.log" and mtime < 1d`, []string{"/r/new.log"}},
+		{`ext != log and size >= 10 and size <= 10`, []string{"/r/keep.txt"}},
+		{`path = "archive/*" or name = keep.txt`, []string{"/r/archive/old.log", "/r/keep.txt"}},
+		{`NOT (ext = log OR ext = txt)`, []string{"/r/big.tmp"}},
+		{`name !~ "^old"`, []string{"/r/new.log", "/r/big.tmp", "/r/keep.txt"}},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseExpr(tt.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.expr, err)
+		}
+		want := make(map[string]bool)
+		for _, path := range tt.want {
+			want[path] = true
+		}
+		for path, info := range files {
+			if got := expr.Eval("/r", path, info); got != want[path] {
+				t.Errorf("%q on %s: expected %v, got %v", tt.expr, path, want[path], got)
+			}
+		}
+	}
+}
+
+func TestExprParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{`color = red`, 0},
+		{`size ~ 1K`, 5},
+		{`size > lots`, 7},
+		{`(name = a`, 9},
+		{`name = a b`, 9},
+		{`ext in [log`, 11},
+		{`name = "open`, 7},
+		{`name ~ "("`, 7},
+		{`! name = a`, 0},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseExpr(tt.expr)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: expected a parse error, got %v", tt.expr, err)
+			continue
+		}
+		if perr.Pos != tt.pos {
+			t.Errorf("%q: expected error at %d, got %d (%v)", tt.expr, tt.pos, perr.Pos, err)
+		}
+	}
+}
+
+func TestExprExplain(t *testing.T) {
+	expr, err := ParseExpr(`ext = log or size > 1K`)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	matched, why := expr.Explain("/r", "/r/a.log", fakeInfo{size: 10, modTime: time.Now()})
+	if !matched || why != `ext = log (ext is "log")` {
+		t.Errorf("unexpected explanation %v %q", matched, why)
+	}
+}
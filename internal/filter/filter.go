@@ -2,10 +2,13 @@
 package filter
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"time"
+
+	"nuke/internal/utils"
 )
 
 // Options represents filtering options for file selection
@@ -14,21 +17,99 @@ type Options struct {
 	OlderThan *time.Time // Only files older than this time
 	NewerThan *time.Time // Only files newer than this time
 
+	// Access and inode change time filters
+	AccessedBefore *time.Time // Only files last read before this time
+	AccessedAfter  *time.Time // Only files last read after this time
+	ChangedBefore  *time.Time // Only files whose inode changed before this time
+	ChangedAfter   *time.Time // Only files whose inode changed after this time
+
 	// Size-based filters
 	SizeFilter int64  // Size threshold in bytes
 	SizeOp     string // Operator: "+" for greater than, "-" for less than
 
+	// Type, ownership and permission filters
+	Types  []string // Entry types to match (see ParseTypes)
+	UID    *uint32  // Only files owned by this user
+	GID    *uint32  // Only files owned by this group
+	Perm   uint32   // Permission bits, compared according to PermOp
+	PermOp string   // "=" exact, "-" all bits set, "/" any bit set ("" disables)
+	Empty  bool     // Only empty files and empty directories
+
+	// Depth limits below the scan target, which is at depth 0
+	MinDepth int // Shallowest depth matched
+	MaxDepth int // Deepest depth matched and walked (0 for no limit)
+
 	// Pattern-based filters
-	Exclude []string       // Glob patterns to exclude
-	Include []string       // Glob patterns to include (if set, only these match)
+	// gitignore-style patterns, relative to the scan target. Excluding a
+	// directory excludes its subtree; including one includes its subtree.
+	Exclude Patterns       // Patterns to exclude
+	Include Patterns       // Patterns to include (if set, only these match)
 	Regex   *regexp.Regexp // Regex pattern to match
 
 	// Skip hidden files
 	SkipHidden bool
+
+	// Ignore-file based selection and protection
+	Ignore *Ignore
+
+	// Build and cache artifacts of the selected ecosystems
+	Profiles *Profiles
+
+	// Boolean expression evaluated after the metadata filters
+	Where *Expr
+
+	// Content filters, evaluated last as they read the file
+	MIME            []string       // MIME type globs such as "image/*" (see ParseMIME)
+	Contains        *regexp.Regexp // Only files whose content matches
+	ContainsMaxSize int64          // Larger files are not searched (0 for no limit)
+}
+
+// Active reports whether any filter is set, i.e. whether Match can reject
+// a file
+func (o *Options) Active() bool {
+	if o == nil {
+		return false
+	}
+	return o.OlderThan != nil || o.NewerThan != nil || o.SizeFilter > 0 ||
+		o.AccessedBefore != nil || o.AccessedAfter != nil || o.ChangedBefore != nil || o.ChangedAfter != nil ||
+		len(o.Types) > 0 || o.UID != nil || o.GID != nil || o.PermOp != "" || o.Empty ||
+		o.MinDepth > 0 || o.MaxDepth > 0 ||
+		len(o.Include) > 0 || len(o.Exclude) > 0 || o.Regex != nil || o.SkipHidden ||
+		(o.Ignore != nil && (o.Ignore.GitIgnored || o.Ignore.Untracked || o.Ignore.NukeIgnore)) || o.Where != nil ||
+		len(o.MIME) > 0 || o.Contains != nil || o.Profiles != nil
+}
+
+// Prune reports whether a directory beneath the scan target root is
+// excluded together with everything beneath it, so a walk should not enter
+// it at all
+func (o *Options) Prune(root, path string) bool {
+	if o == nil {
+		return false
+	}
+	if o.SkipHidden && isHidden(path) {
+		return true
+	}
+	if o.MaxDepth > 0 && depthOf(root, path) > o.MaxDepth {
+		return true
+	}
+	if o.Ignore != nil && (o.Ignore.protects(path, true) || o.Ignore.prunes(root, path)) {
+		return true
+	}
+	excluded, _ := o.Exclude.MatchIn(root, path, true)
+	return excluded
 }
 
-// Match checks if a file matches the filter criteria
-func (o *Options) Match(path string, info os.FileInfo) bool {
+// AtMaxDepth reports whether a directory beneath the scan target root is at
+// the depth limit, so nothing beneath it can match and a walk only needs to
+// know whether it is empty
+func (o *Options) AtMaxDepth(root, dir string) bool {
+	return o != nil && o.MaxDepth > 0 && depthOf(root, dir) >= o.MaxDepth
+}
+
+// Match checks if a file beneath the scan target root matches the filter
+// criteria. Walks are expected to prune excluded directories, so only the
+// file itself is checked against the excludes.
+func (o *Options) Match(root, path string, info os.FileInfo) bool {
 	if o == nil {
 		return true
 	}
@@ -38,6 +119,14 @@ func (o *Options) Match(path string, info os.FileInfo) bool {
 		return false
 	}
 
+	// Check depth limits
+	if o.MinDepth > 0 || o.MaxDepth > 0 {
+		depth := depthOf(root, path)
+		if depth < o.MinDepth || (o.MaxDepth > 0 && depth > o.MaxDepth) {
+			return false
+		}
+	}
+
 	// Check time-based filters
 	if o.OlderThan != nil {
 		if info.ModTime().After(*o.OlderThan) {
@@ -51,6 +140,30 @@ func (o *Options) Match(path string, info os.FileInfo) bool {
 		}
 	}
 
+	if o.AccessedBefore != nil && accessTime(info).After(*o.AccessedBefore) {
+		return false
+	}
+	if o.AccessedAfter != nil && accessTime(info).Before(*o.AccessedAfter) {
+		return false
+	}
+	if o.ChangedBefore != nil && changeTime(info).After(*o.ChangedBefore) {
+		return false
+	}
+	if o.ChangedAfter != nil && changeTime(info).Before(*o.ChangedAfter) {
+		return false
+	}
+
+	// Check ownership and permissions
+	if o.UID != nil || o.GID != nil {
+		uid, gid, ok := ownerOf(info)
+		if !ok || (o.UID != nil && uid != *o.UID) || (o.GID != nil && gid != *o.GID) {
+			return false
+		}
+	}
+	if o.PermOp != "" && !matchPerm(o.Perm, o.PermOp, info.Mode()) {
+		return false
+	}
+
 	// Check size-based filters (only for regular files)
 	if o.SizeFilter > 0 && !info.IsDir() {
 		switch o.SizeOp {
@@ -65,38 +178,37 @@ func (o *Options) Match(path string, info os.FileInfo) bool {
 		}
 	}
 
-	// Check include patterns (if set, file must match at least one)
-	if len(o.Include) > 0 {
-		matched := false
-		baseName := filepath.Base(path)
-		for _, pattern := range o.Include {
-			if match, _ := filepath.Match(pattern, baseName); match {
-				matched = true
-				break
-			}
-			// Also try matching against full path
-			if match, _ := filepath.Match(pattern, path); match {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
+	// Check include patterns (if set, file must be in an included subtree)
+	if len(o.Include) > 0 && !o.Include.MatchesUnder(root, path, info.IsDir()) {
+		return false
 	}
 
 	// Check exclude patterns
-	if len(o.Exclude) > 0 {
-		baseName := filepath.Base(path)
-		for _, pattern := range o.Exclude {
-			if match, _ := filepath.Match(pattern, baseName); match {
-				return false
-			}
-			// Also try matching against full path
-			if match, _ := filepath.Match(pattern, path); match {
-				return false
-			}
-		}
+	if excluded, _ := o.Exclude.MatchIn(root, path, info.IsDir()); excluded {
+		return false
+	}
+
+	// Checks that may need another system call
+	if len(o.Types) > 0 && !matchType(o.Types, path, info) {
+		return false
+	}
+	if o.Empty && !isEmpty(path, info) {
+		return false
+	}
+
+	// Check ignore files
+	if o.Ignore != nil && (o.Ignore.protects(path, info.IsDir()) || !o.Ignore.selects(root, path, info.IsDir())) {
+		return false
+	}
+
+	// Check profiles
+	if o.Profiles != nil && !o.Profiles.selects(root, path, info.IsDir()) {
+		return false
+	}
+
+	// Check the expression
+	if o.Where != nil && !o.Where.Eval(root, path, info) {
+		return false
 	}
 
 	// Check regex pattern
@@ -106,9 +218,102 @@ func (o *Options) Match(path string, info os.FileInfo) bool {
 		}
 	}
 
+	// Check content
+	if len(o.MIME) > 0 && !matchMIME(o.MIME, mimeOf(path, info)) {
+		return false
+	}
+	if o.Contains != nil {
+		if found, _ := contains(o.Contains, o.ContainsMaxSize, path, info); !found {
+			return false
+		}
+	}
+
 	return true
 }
 
+// Explain describes why a file matches, one reason per active criterion
+// that selects files
+func (o *Options) Explain(root, path string, info os.FileInfo) []string {
+	if !o.Active() {
+		return []string{"no filters, everything matches"}
+	}
+
+	var reasons []string
+	age := formatAge(time.Since(info.ModTime()))
+	if o.OlderThan != nil {
+		reasons = append(reasons, fmt.Sprintf("modified before %s (%s)", o.OlderThan.Format("2006-01-02 15:04"), age))
+	}
+	if o.NewerThan != nil {
+		reasons = append(reasons, fmt.Sprintf("modified after %s (%s)", o.NewerThan.Format("2006-01-02 15:04"), age))
+	}
+	if o.AccessedBefore != nil {
+		reasons = append(reasons, fmt.Sprintf("last read before %s (%s)", o.AccessedBefore.Format("2006-01-02 15:04"), formatAge(time.Since(accessTime(info)))))
+	}
+	if o.AccessedAfter != nil {
+		reasons = append(reasons, fmt.Sprintf("last read after %s (%s)", o.AccessedAfter.Format("2006-01-02 15:04"), formatAge(time.Since(accessTime(info)))))
+	}
+	if o.ChangedBefore != nil {
+		reasons = append(reasons, fmt.Sprintf("changed before %s (%s)", o.ChangedBefore.Format("2006-01-02 15:04"), formatAge(time.Since(changeTime(info)))))
+	}
+	if o.ChangedAfter != nil {
+		reasons = append(reasons, fmt.Sprintf("changed after %s (%s)", o.ChangedAfter.Format("2006-01-02 15:04"), formatAge(time.Since(changeTime(info)))))
+	}
+	if len(o.Types) > 0 {
+		reasons = append(reasons, "type "+entryType(path, info))
+	}
+	if o.UID != nil || o.GID != nil {
+		uid, gid, _ := ownerOf(info)
+		reasons = append(reasons, fmt.Sprintf("owned by %s:%s", userName(uid), groupName(gid)))
+	}
+	if o.PermOp != "" {
+		reasons = append(reasons, fmt.Sprintf("permissions %04o", permBits(info.Mode())))
+	}
+	if o.Empty {
+		reasons = append(reasons, "empty")
+	}
+	if o.MinDepth > 0 || o.MaxDepth > 0 {
+		reasons = append(reasons, fmt.Sprintf("depth %d", depthOf(root, path)))
+	}
+	if o.SizeFilter > 0 && !info.IsDir() {
+		op := map[string]string{"+": ">", "-": "<"}[o.SizeOp]
+		reasons = append(reasons, fmt.Sprintf("size %s %s (%s)", op, utils.FormatSize(o.SizeFilter), utils.FormatSize(info.Size())))
+	}
+	if len(o.Include) > 0 {
+		reasons = append(reasons, "matches --include")
+	}
+	if o.Regex != nil {
+		reasons = append(reasons, fmt.Sprintf("matches regex %q", o.Regex.String()))
+	}
+	if o.Ignore != nil && o.Ignore.GitIgnored {
+		reasons = append(reasons, "ignored by git")
+	} else if o.Ignore != nil && o.Ignore.Untracked {
+		reasons = append(reasons, "not tracked by git")
+	}
+	if o.Profiles != nil {
+		if profile, artifact, ok := o.Profiles.artifact(root, path, info.IsDir()); ok {
+			reasons = append(reasons, fmt.Sprintf("%s artifact %s", profile, artifact))
+		}
+	}
+	if o.Where != nil {
+		_, why := o.Where.Explain(root, path, info)
+		reasons = append(reasons, "where "+why)
+	}
+	if len(o.MIME) > 0 {
+		reasons = append(reasons, "content is "+mimeOf(path, info))
+	}
+	if o.Contains != nil {
+		_, match := contains(o.Contains, o.ContainsMaxSize, path, info)
+		if len(match) > 40 {
+			match = match[:40] + "..."
+		}
+		reasons = append(reasons, fmt.Sprintf("contains %q", match))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "not excluded")
+	}
+	return reasons
+}
+
 // isHidden checks if a file is hidden (starts with .)
 func isHidden(path string) bool {
 	baseName := filepath.Base(path)
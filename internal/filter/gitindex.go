@@ -0,0 +1,197 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Index entry modes that stand for a whole tree
+const (
+	modeGitlink = 0160000 // Submodule
+	modeSparse  = 0040000 // Directory collapsed by a sparse index
+)
+
+// Tracked is the set of paths a git repository tracks, read from its index
+type Tracked struct {
+	workTree string
+	files    map[string]bool // Tracked entries, relative to the work tree
+	dirs     map[string]bool // Directories holding tracked entries
+	trees    map[string]bool // Directories tracked as a whole: submodules, sparse directories and .git
+}
+
+// GitTracked reads the index of the git repository containing path
+func GitTracked(path string) (*Tracked, error) {
+	workTree, gitDir, err := findRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashSize := 20
+	if strings.EqualFold(gitConfigValue(filepath.Join(gitCommonDir(gitDir), "config"), "extensions", "objectformat"), "sha256") {
+		hashSize = 32
+	}
+
+	t := &Tracked{
+		workTree: workTree,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]bool),
+		trees:    map[string]bool{".git": true},
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "index"))
+	if os.IsNotExist(err) {
+		// Nothing has been added yet
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := t.parse(data, hashSize); err != nil {
+		return nil, fmt.Errorf("failed to read git index: %w", err)
+	}
+	return t, nil
+}
+
+// Has reports whether git tracks a path beneath the work tree, or for a
+// directory, anything beneath it. Everything in .git and in submodules
+// counts as tracked.
+func (t *Tracked) Has(path string, isDir bool) bool {
+	rel, err := filepath.Rel(t.workTree, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if t.Whole(path) {
+		return true
+	}
+	if isDir {
+		return rel == "." || t.dirs[rel]
+	}
+	return t.files[rel]
+}
+
+// Whole reports whether a path is in a tree git tracks as a whole, so a
+// walk need not enter it
+func (t *Tracked) Whole(path string) bool {
+	rel, err := filepath.Rel(t.workTree, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	for rel = filepath.ToSlash(rel); rel != "." && rel != ".."; rel = pathDir(rel) {
+		if t.trees[rel] || strings.HasSuffix(rel, "/.git") {
+			return true
+		}
+	}
+	return false
+}
+
+// pathDir returns the parent of a slash separated relative path
+func pathDir(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return "."
+}
+
+// parse reads index versions 2 to 4. Split indexes keep most entries in a
+// shared file and are refused rather than read in part.
+func (t *Tracked) parse(data []byte, hashSize int) error {
+	if len(data) < 12+hashSize || string(data[:4]) != "DIRC" {
+		return errors.New("not an index file")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return fmt.Errorf("unsupported index version %d", version)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+	body := data[:len(data)-hashSize]
+
+	pos := 12
+	prev := ""
+	for i := uint32(0); i < count; i++ {
+		// ctime, mtime, dev, ino, mode, uid, gid, size, object name, flags
+		fixed := 40 + hashSize + 2
+		if pos+fixed > len(body) {
+			return errors.New("truncated entry")
+		}
+		mode := binary.BigEndian.Uint32(body[pos+24 : pos+28])
+		flags := binary.BigEndian.Uint16(body[pos+40+hashSize : pos+fixed])
+		if version >= 3 && flags&0x4000 != 0 {
+			fixed += 2
+		}
+
+		var name string
+		start := pos + fixed
+		if version == 4 {
+			// The name shares a prefix with the previous one
+			strip, n := readVarint(body[start:])
+			if n == 0 || strip > uint64(len(prev)) {
+				return errors.New("corrupt entry name")
+			}
+			end := bytes.IndexByte(body[start+n:], 0)
+			if end < 0 {
+				return errors.New("truncated entry name")
+			}
+			name = prev[:len(prev)-int(strip)] + string(body[start+n:start+n+end])
+			pos = start + n + end + 1
+		} else {
+			end := bytes.IndexByte(body[start:], 0)
+			if end < 0 {
+				return errors.New("truncated entry name")
+			}
+			name = string(body[start : start+end])
+			// Entries are padded with 1 to 8 NULs to a multiple of 8 bytes
+			pos += (fixed + end + 8) &^ 7
+		}
+		prev = name
+		t.add(name, mode)
+	}
+
+	for pos+8 <= len(body) {
+		signature := string(body[pos : pos+4])
+		size := int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+		if signature == "link" {
+			return errors.New("split indexes are not supported")
+		}
+		pos += 8 + size
+	}
+	return nil
+}
+
+// add records an index entry and the directories leading to it
+func (t *Tracked) add(name string, mode uint32) {
+	name = strings.TrimSuffix(name, "/")
+	switch mode & 0170000 {
+	case modeGitlink, modeSparse:
+		t.trees[name] = true
+	default:
+		t.files[name] = true
+	}
+	for dir := pathDir(name); dir != "." && !t.dirs[dir]; dir = pathDir(dir) {
+		t.dirs[dir] = true
+	}
+}
+
+// readVarint decodes the offset varint used by index version 4, returning
+// the value and the number of bytes read (0 if truncated)
+func readVarint(b []byte) (uint64, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	c := b[0]
+	val := uint64(c & 127)
+	n := 1
+	for c&128 != 0 {
+		if n >= len(b) {
+			return 0, 0
+		}
+		c = b[n]
+		n++
+		val = (val+1)<<7 | uint64(c&127)
+	}
+	return val, n
+}
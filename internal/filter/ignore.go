@@ -0,0 +1,356 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNotInRepo is returned when a path is not inside a git repository
+var ErrNotInRepo = errors.New("not inside a git repository")
+
+// IgnoreFiles evaluates a hierarchy of per-directory ignore files such as
+// .gitignore. Patterns in a file apply relative to its directory, deeper
+// files override shallower ones, and nothing beneath an ignored directory
+// can be re-included. Files are read once and cached.
+type IgnoreFiles struct {
+	root  string   // Directory the hierarchy starts at
+	base  Patterns // Patterns relative to root, overridden by every file
+	names []string // Names of the ignore files read in each directory
+
+	mu       sync.Mutex
+	patterns map[string]Patterns // Patterns of each directory read so far
+	dirs     map[string]bool     // Whether each directory looked up is ignored
+}
+
+// NewIgnoreFiles creates an evaluator for the ignore files with the given
+// names in root and every directory beneath it
+func NewIgnoreFiles(root string, base Patterns, names ...string) *IgnoreFiles {
+	return &IgnoreFiles{
+		root:     filepath.Clean(root),
+		base:     base,
+		names:    names,
+		patterns: make(map[string]Patterns),
+		dirs:     make(map[string]bool),
+	}
+}
+
+// Ignored reports whether a path beneath the root is ignored, either itself
+// or because a directory above it is
+func (f *IgnoreFiles) Ignored(path string, isDir bool) bool {
+	path = filepath.Clean(path)
+	if path == f.root || !within(f.root, path) {
+		return false
+	}
+	if f.ignoredDir(filepath.Dir(path)) {
+		return true
+	}
+	return f.decide(path, isDir)
+}
+
+// ignoredDir reports whether a directory is ignored, remembering the answer
+func (f *IgnoreFiles) ignoredDir(dir string) bool {
+	if dir == f.root || !within(f.root, dir) {
+		return false
+	}
+	f.mu.Lock()
+	ignored, ok := f.dirs[dir]
+	f.mu.Unlock()
+	if ok {
+		return ignored
+	}
+
+	ignored = f.ignoredDir(filepath.Dir(dir)) || f.decide(dir, true)
+	f.mu.Lock()
+	f.dirs[dir] = ignored
+	f.mu.Unlock()
+	return ignored
+}
+
+// decide applies the ignore files from the deepest directory up, the first
+// one with a matching pattern deciding
+func (f *IgnoreFiles) decide(path string, isDir bool) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if matched, decided := f.load(dir).Match(relTo(dir, path), isDir); decided {
+			return matched
+		}
+		if dir == f.root || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	matched, _ := f.base.Match(relTo(f.root, path), isDir)
+	return matched
+}
+
+// load returns the patterns of the ignore files in a directory
+func (f *IgnoreFiles) load(dir string) Patterns {
+	f.mu.Lock()
+	ps, ok := f.patterns[dir]
+	f.mu.Unlock()
+	if ok {
+		return ps
+	}
+
+	for _, name := range f.names {
+		ps = append(ps, readPatterns(filepath.Join(dir, name))...)
+	}
+	f.mu.Lock()
+	f.patterns[dir] = ps
+	f.mu.Unlock()
+	return ps
+}
+
+// readPatterns reads an ignore file. Missing files and invalid patterns
+// are skipped, as git does.
+func readPatterns(path string) Patterns {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	var ps Patterns
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if p, err := ParsePattern(scanner.Text()); err == nil && p != nil {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// within reports whether path is root or beneath it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Ignore selects or protects paths with ignore files. Paths git tracks are
+// never selected, as git never treats them as ignored.
+type Ignore struct {
+	GitIgnored bool // Match only paths ignored by git
+	Untracked  bool // Match only paths git does not track
+	NukeIgnore bool // Leave paths listed in .nukeignore files alone
+
+	mu   sync.Mutex
+	git  map[string]*repo // Repository of each scan target
+	nuke *IgnoreFiles
+}
+
+// repo holds what is read from a scan target's repository
+type repo struct {
+	ignore  *IgnoreFiles
+	tracked *Tracked
+	err     error
+}
+
+// Check returns an error if the modes cannot apply to a scan target
+func (ig *Ignore) Check(target string) error {
+	if !ig.GitIgnored && !ig.Untracked {
+		return nil
+	}
+	if r := ig.gitFor(target); r.err != nil {
+		return fmt.Errorf("%s: %w", target, r.err)
+	}
+	return nil
+}
+
+// protects reports whether a .nukeignore file lists a path
+func (ig *Ignore) protects(path string, isDir bool) bool {
+	if !ig.NukeIgnore {
+		return false
+	}
+	ig.mu.Lock()
+	if ig.nuke == nil {
+		// .nukeignore files above the target apply as well
+		abs, _ := filepath.Abs(path)
+		ig.nuke = NewIgnoreFiles(filepath.VolumeName(abs)+string(filepath.Separator), nil, ".nukeignore")
+	}
+	nuke := ig.nuke
+	ig.mu.Unlock()
+	return nuke.Ignored(path, isDir)
+}
+
+// selects reports whether a path beneath the scan target root is untracked
+// and, with GitIgnored, ignored by git. Directories holding tracked files
+// are never selected.
+func (ig *Ignore) selects(root, path string, isDir bool) bool {
+	if !ig.GitIgnored && !ig.Untracked {
+		return true
+	}
+	r := ig.gitFor(root)
+	if r.err != nil || r.tracked.Has(path, isDir) {
+		return false
+	}
+	return !ig.GitIgnored || r.ignore.Ignored(path, isDir)
+}
+
+// prunes reports whether a directory is tracked by git as a whole, such as
+// .git or a submodule, or is another repository, as git clean leaves it,
+// so nothing beneath it can be selected
+func (ig *Ignore) prunes(root, path string) bool {
+	if !ig.GitIgnored && !ig.Untracked {
+		return false
+	}
+	r := ig.gitFor(root)
+	switch {
+	case r.err != nil:
+		return false
+	case r.tracked.Whole(path):
+		return true
+	}
+	_, err := os.Lstat(filepath.Join(path, ".git"))
+	return err == nil && filepath.Clean(path) != r.tracked.workTree
+}
+
+// gitFor reads the repository containing a scan target once
+func (ig *Ignore) gitFor(root string) *repo {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if r, ok := ig.git[root]; ok {
+		return r
+	}
+	if ig.git == nil {
+		ig.git = make(map[string]*repo)
+	}
+	r := &repo{}
+	if r.ignore, r.err = GitIgnore(root); r.err == nil {
+		r.tracked, r.err = GitTracked(root)
+	}
+	ig.git[root] = r
+	return r
+}
+
+// GitIgnore returns the ignore rules of the git repository containing path:
+// the .gitignore files of the work tree, .git/info/exclude and the file
+// named by core.excludesFile
+func GitIgnore(path string) (*IgnoreFiles, error) {
+	workTree, gitDir, err := findRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	commonDir := gitCommonDir(gitDir)
+
+	// Lowest precedence first, as the last match wins
+	var base Patterns
+	if excludesFile := gitExcludesFile(commonDir); excludesFile != "" {
+		base = append(base, readPatterns(excludesFile)...)
+	}
+	base = append(base, readPatterns(filepath.Join(commonDir, "info", "exclude"))...)
+
+	return NewIgnoreFiles(workTree, base, ".gitignore"), nil
+}
+
+// gitCommonDir returns the directory holding the config and info/ of a
+// repository; linked work trees share them with the main repository
+func gitCommonDir(gitDir string) string {
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		return resolve(gitDir, strings.TrimSpace(string(data)))
+	}
+	return gitDir
+}
+
+// findRepo finds the work tree and git directory containing path
+func findRepo(path string) (workTree, gitDir string, err error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return dir, dotGit, nil
+			}
+			// Submodules and linked work trees point elsewhere
+			if data, err := os.ReadFile(dotGit); err == nil {
+				if target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:"); ok {
+					return dir, resolve(dir, strings.TrimSpace(target)), nil
+				}
+			}
+		}
+		if dir == filepath.Dir(dir) {
+			return "", "", ErrNotInRepo
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// gitExcludesFile returns the file named by core.excludesFile in the
+// user's and the repository's git config, or git's default
+func gitExcludesFile(gitDir string) string {
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+
+	var configs []string
+	if xdg != "" {
+		configs = append(configs, filepath.Join(xdg, "git", "config"))
+	}
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+	configs = append(configs, filepath.Join(gitDir, "config"))
+
+	excludesFile := ""
+	if xdg != "" {
+		excludesFile = filepath.Join(xdg, "git", "ignore")
+	}
+	for _, config := range configs {
+		if value := gitConfigValue(config, "core", "excludesfile"); value != "" {
+			excludesFile = value
+		}
+	}
+
+	if strings.HasPrefix(excludesFile, "~/") && home != "" {
+		excludesFile = filepath.Join(home, excludesFile[2:])
+	}
+	return excludesFile
+}
+
+// gitConfigValue reads a single key from a git config file. Only the
+// simple "key = value" form is understood; includes are not followed.
+func gitConfigValue(path, section, key string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	value := ""
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[':
+			current = strings.ToLower(strings.TrimSpace(strings.Trim(line, "[]")))
+			continue
+		}
+		if current != section {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), key) {
+			value = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return value
+}
+
+// resolve makes a path relative to dir absolute
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,312 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitIgnore(t *testing.T) {
+	repo, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(repo) }()
+
+	// Keep the user's own git config out of the test
+	t.Setenv("HOME", repo)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(repo, "xdg"))
+
+	files := map[string]string{
+		".git/info/exclude":    "*.swp\n",
+		".git/config":          "[core]\n\texcludesFile = " + filepath.Join(repo, "global-ignore") + "\n",
+		"global-ignore":        "*.bak\n",
+		".gitignore":           "build/\n*.log\n!keep.log\n",
+		"src/.gitignore":       "gen/\n!local.log\n",
+		"src/gen/out.go":       "",
+		"src/local.log":        "",
+		"src/main.go":          "",
+		"build/sub/x.o":        "",
+		"a.log":                "",
+		"keep.log":             "",
+		"notes.swp":            "",
+		"old.bak":              "",
+		"build/.gitignore":     "!*.o\n",
+		"other/deep/trace.log": "",
+	}
+	for name, data := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git, err := GitIgnore(filepath.Join(repo, "src"))
+	if err != nil {
+		t.Fatalf("failed to load ignore rules: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"keep.log", false, false},
+		{"other/deep/trace.log", false, true},
+		{"src/local.log", false, false}, // re-included deeper down
+		{"src/main.go", false, false},
+		{"src/gen", true, true},
+		{"src/gen/out.go", false, true},
+		{"build", true, true},
+		{"build/sub/x.o", false, true}, // cannot re-include under an ignored dir
+		{"notes.swp", false, true},     // .git/info/exclude
+		{"old.bak", false, true},       // core.excludesFile
+		{"src", true, false},
+	}
+	for _, tt := range tests {
+		if got := git.Ignored(filepath.Join(repo, tt.name), tt.isDir); got != tt.want {
+			t.Errorf("%s: expected ignored %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	outside, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(outside) }()
+	if _, err := GitIgnore(outside); err != ErrNotInRepo {
+		t.Errorf("expected ErrNotInRepo outside a repository, got %v", err)
+	}
+}
+
+func TestNukeIgnore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// One .nukeignore above the target and one inside it
+	target := filepath.Join(tmpDir, "target")
+	files := map[string]string{
+		".nukeignore":          "secret.txt\ntarget/keep/\n",
+		"target/.nukeignore":   "cache/\n*.pem\n",
+		"target/secret.txt":    "",
+		"target/keep/a.txt":    "",
+		"target/cache/b.txt":   "",
+		"target/sub/key.pem":   "",
+		"target/sub/notes.txt": "",
+		"target/cache.txt":     "",
+	}
+	for name, data := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	opts := &Options{Ignore: &Ignore{NukeIgnore: true}}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"secret.txt", false},   // listed above the target
+		{"keep", false},         // directory listed above the target
+		{"keep/a.txt", false},   // beneath a protected directory
+		{"cache", false},        // directory listed inside the target
+		{"cache/b.txt", false},  // beneath a protected directory
+		{"sub/key.pem", false},  // listed inside the target
+		{"sub/notes.txt", true}, // not listed
+		{"sub", true},           // holds a protected file but is not listed
+		{"cache.txt", true},     // only the directory cache/ is listed
+	}
+	for _, tt := range tests {
+		path := filepath.Join(target, tt.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", tt.name, err)
+		}
+		if got := opts.Match(target, path, info); got != tt.want {
+			t.Errorf("%s: expected match %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// Protected directories are not entered at all
+	for name, want := range map[string]bool{"keep": true, "cache": true, "sub": false} {
+		if got := opts.Prune(target, filepath.Join(target, name)); got != want {
+			t.Errorf("%s: expected pruned %v, got %v", name, want, got)
+		}
+	}
+
+	// Without --respect-ignore-files the .nukeignore files are not read
+	off := &Options{Ignore: &Ignore{}}
+	path := filepath.Join(target, "secret.txt")
+	info, _ := os.Stat(path)
+	if !off.Match(target, path, info) || off.Prune(target, filepath.Join(target, "keep")) {
+		t.Error("expected .nukeignore files to be ignored when the option is off")
+	}
+}
+
+// writeIndex writes a git index of the given version listing entries,
+// each with its mode
+func writeIndex(t *testing.T, path string, version uint32, entries []indexEntry) {
+	var buf bytes.Buffer
+	buf.WriteString("DIRC")
+	_ = binary.Write(&buf, binary.BigEndian, version)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(entries)))
+	prev := ""
+	for _, e := range entries {
+		start := buf.Len()
+		fixed := make([]byte, 62)
+		binary.BigEndian.PutUint32(fixed[24:28], e.mode)
+		binary.BigEndian.PutUint16(fixed[60:62], uint16(len(e.name)))
+		buf.Write(fixed)
+		if version == 4 {
+			// Share nothing with the previous name, then the whole name
+			buf.WriteByte(byte(len(prev)))
+			buf.WriteString(e.name)
+			buf.WriteByte(0)
+		} else {
+			buf.WriteString(e.name)
+			buf.Write(make([]byte, 8-(buf.Len()-start)%8))
+		}
+		prev = e.name
+	}
+	buf.Write(make([]byte, 20))
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+}
+
+type indexEntry struct {
+	name string
+	mode uint32
+}
+
+func TestGitTracked(t *testing.T) {
+	for _, version := range []uint32{2, 4} {
+		repo, err := os.MkdirTemp("", "nuke-filter-test")
+		if err != nil {
+			t.Fatalf("failed to create temp dir: %v", err)
+		}
+		defer func() { _ = os.RemoveAll(repo) }()
+		if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		writeIndex(t, filepath.Join(repo, ".git", "index"), version, []indexEntry{
+			{".gitignore", 0100644},
+			{"build/keep.log", 0100644},
+			{"lib/sub", modeGitlink},
+			{"src/main.go", 0100755},
+		})
+
+		tracked, err := GitTracked(repo)
+		if err != nil {
+			t.Fatalf("v%d: failed to read index: %v", version, err)
+		}
+		tests := []struct {
+			name  string
+			isDir bool
+			want  bool
+		}{
+			{"build/keep.log", false, true},
+			{"build", true, true}, // holds a tracked file
+			{"build/out.o", false, false},
+			{"src/main.go", false, true},
+			{"src/new.go", false, false},
+			{"lib", true, true},
+			{"lib/sub/anything", false, true}, // inside a submodule
+			{".git/config", false, true},
+			{"scratch", true, false},
+		}
+		for _, tt := range tests {
+			if got := tracked.Has(filepath.Join(repo, tt.name), tt.isDir); got != tt.want {
+				t.Errorf("v%d %s: expected tracked %v, got %v", version, tt.name, tt.want, got)
+			}
+		}
+	}
+}
+
+func TestIgnoreSkipsTracked(t *testing.T) {
+	repo, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(repo) }()
+	t.Setenv("HOME", repo)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(repo, "xdg"))
+
+	files := map[string]string{
+		".gitignore":        "build/\n*.log\n",
+		"build/keep.log":    "",
+		"build/out.o":       "",
+		"debug.log":         "",
+		"src/main.go":       "",
+		"src/new.go":        "",
+		"vendor/dep/.git/x": "",
+		"vendor/dep/a.go":   "",
+	}
+	for name, data := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	// build/keep.log matches both patterns but was committed anyway
+	writeIndex(t, filepath.Join(repo, ".git", "index"), 2, []indexEntry{
+		{".gitignore", 0100644},
+		{"build/keep.log", 0100644},
+		{"src/main.go", 0100644},
+	})
+
+	tests := []struct {
+		name       string
+		ignored    bool // Selected by --gitignored
+		notTracked bool // Selected by --untracked
+	}{
+		{"build/keep.log", false, false},
+		{"build", false, false}, // would take the tracked file with it
+		{"build/out.o", true, true},
+		{"debug.log", true, true},
+		{"src/main.go", false, false},
+		{"src/new.go", false, true},
+		{".gitignore", false, false},
+	}
+	gitignored := &Options{Ignore: &Ignore{GitIgnored: true}}
+	untracked := &Options{Ignore: &Ignore{Untracked: true}}
+	for _, tt := range tests {
+		path := filepath.Join(repo, tt.name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", tt.name, err)
+		}
+		if got := gitignored.Match(repo, path, info); got != tt.ignored {
+			t.Errorf("--gitignored %s: expected match %v, got %v", tt.name, tt.ignored, got)
+		}
+		if got := untracked.Match(repo, path, info); got != tt.notTracked {
+			t.Errorf("--untracked %s: expected match %v, got %v", tt.name, tt.notTracked, got)
+		}
+	}
+
+	// .git and nested repositories are never entered
+	for name, want := range map[string]bool{".git": true, "vendor/dep": true, "vendor": false, "src": false} {
+		if got := untracked.Prune(repo, filepath.Join(repo, name)); got != want {
+			t.Errorf("%s: expected pruned %v, got %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a compiled gitignore-style pattern:
+//   - "*" and "?" match within a path segment, "[...]" matches a class
+//   - "**" as a whole segment matches any number of directories
+//   - a leading "!" negates the pattern, re-including what it matches
+//   - a leading "/" or a "/" in the middle anchors the pattern to the base
+//     directory; without one it matches the name at any depth
+//   - a trailing "/" only matches directories
+type Pattern struct {
+	raw      string
+	negate   bool
+	dirOnly  bool
+	anchored bool // Matched against the whole relative path
+	absolute bool // Started with "/", so may also be an absolute path
+	re       *regexp.Regexp
+}
+
+// ParsePattern compiles a single pattern. Blank lines and comments return
+// a nil Pattern.
+func ParsePattern(line string) (*Pattern, error) {
+	line = trimTrailingSpace(line)
+	if line == "" || line[0] == '#' {
+		return nil, nil
+	}
+
+	p := &Pattern{raw: line}
+	if line[0] == '!' {
+		p.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.HasPrefix(line, "/") {
+		p.absolute = true
+		line = strings.TrimLeft(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+	p.anchored = p.absolute || strings.Contains(line, "/")
+
+	re, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p.raw, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// String returns the pattern as it was written
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// match reports whether the pattern matches a path given relative to the
+// base directory (empty for the base itself) and as an absolute path
+// without its leading slash (empty if not applicable)
+func (p *Pattern) match(rel, abs string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.absolute && abs != "" && p.re.MatchString(abs) {
+		return true
+	}
+	if rel == "" {
+		// The base itself can only be matched by name
+		return !p.anchored && abs != "" && p.re.MatchString(path.Base(abs))
+	}
+	if p.anchored {
+		return p.re.MatchString(rel)
+	}
+	return p.re.MatchString(path.Base(rel))
+}
+
+// Patterns is an ordered list of patterns in which the last match wins
+type Patterns []*Pattern
+
+// ParsePatterns compiles a list of patterns, skipping blank lines and
+// comments
+func ParsePatterns(lines []string) (Patterns, error) {
+	var ps Patterns
+	for _, line := range lines {
+		p, err := ParsePattern(line)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			ps = append(ps, p)
+		}
+	}
+	return ps, nil
+}
+
+// Match reports whether the last pattern matching rel, a slash-separated
+// path relative to the directory the patterns apply to, is a positive one,
+// and whether any pattern matched at all
+func (ps Patterns) Match(rel string, isDir bool) (matched, decided bool) {
+	return ps.match(rel, "", isDir)
+}
+
+// MatchIn is like Match for an absolute path beneath root. Patterns
+// starting with "/" are anchored to root, or match path as an absolute
+// path.
+func (ps Patterns) MatchIn(root, path string, isDir bool) (matched, decided bool) {
+	return ps.match(relTo(root, path), strings.TrimPrefix(filepath.ToSlash(path), "/"), isDir)
+}
+
+// MatchesUnder reports whether path or one of its directories below root
+// is matched, i.e. whether path is part of a matched subtree
+func (ps Patterns) MatchesUnder(root, path string, isDir bool) bool {
+	rel := relTo(root, path)
+	abs := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := 0; i < len(rel); i++ {
+		if rel[i] != '/' {
+			continue
+		}
+		// The directory rel[:i] is abs without the rest of rel
+		dirAbs := strings.TrimSuffix(abs, rel[i:])
+		if matched, _ := ps.match(rel[:i], dirAbs, true); matched {
+			return true
+		}
+	}
+	matched, _ := ps.match(rel, abs, isDir)
+	return matched
+}
+
+// match applies the patterns in reverse order
+func (ps Patterns) match(rel, abs string, isDir bool) (matched, decided bool) {
+	for i := len(ps) - 1; i >= 0; i-- {
+		if ps[i].match(rel, abs, isDir) {
+			return !ps[i].negate, true
+		}
+	}
+	return false, false
+}
+
+// relTo returns path relative to root in slash form, empty for root
+// itself. Paths outside root are made relative to the filesystem root.
+func relTo(root, p string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if rel == "." {
+				return ""
+			}
+			return filepath.ToSlash(rel)
+		}
+	}
+	return strings.TrimPrefix(filepath.ToSlash(p), "/")
+}
+
+// trimTrailingSpace removes trailing spaces that are not escaped
+func trimTrailingSpace(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// globToRegexp translates a gitignore glob into a regular expression
+func globToRegexp(g string) string {
+	var b strings.Builder
+	for i := 0; i < len(g); i++ {
+		switch g[i] {
+		case '*':
+			// "**" is only special as a whole segment
+			if i+1 < len(g) && g[i+1] == '*' && (i == 0 || g[i-1] == '/') {
+				end := i + 2
+				for end < len(g) && g[end] == '*' {
+					end++
+				}
+				switch {
+				case end == len(g):
+					b.WriteString(".*")
+					i = end - 1
+					continue
+				case g[end] == '/':
+					b.WriteString("(?:.*/)?")
+					i = end
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := i + 1
+			if end < len(g) && (g[end] == '!' || g[end] == '^') {
+				end++
+			}
+			if end < len(g) && g[end] == ']' {
+				end++
+			}
+			for end < len(g) && g[end] != ']' {
+				end++
+			}
+			if end >= len(g) {
+				// Unterminated, so a literal bracket
+				b.WriteString(`\[`)
+				continue
+			}
+			class := g[i+1 : end]
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		case '\\':
+			if i+1 < len(g) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(g[i : i+1]))
+		}
+	}
+	return b.String()
+}
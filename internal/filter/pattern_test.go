@@ -0,0 +1,78 @@
+package filter
+
+import "testing"
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		{[]string{"*.o"}, "a.o", false, true},
+		{[]string{"*.o"}, "src/deep/a.o", false, true},
+		{[]string{"*.o"}, "a.c", false, false},
+		{[]string{"src/*.o"}, "src/a.o", false, true},
+		{[]string{"src/*.o"}, "src/x/a.o", false, false},
+		{[]string{"src/*.o"}, "lib/src/a.o", false, false},
+		{[]string{"/build"}, "build", true, true},
+		{[]string{"/build"}, "sub/build", true, false},
+		{[]string{"build/"}, "build", false, false},
+		{[]string{"build/"}, "sub/build", true, true},
+		{[]string{"**/cache"}, "cache", true, true},
+		{[]string{"**/cache"}, "a/b/cache", true, true},
+		{[]string{"a/**/b"}, "a/b", false, true},
+		{[]string{"a/**/b"}, "a/x/y/b", false, true},
+		{[]string{"logs/**"}, "logs/x/y.log", false, true},
+		{[]string{"logs/**"}, "logs", true, false},
+		{[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{[]string{"*.log", "!keep.log"}, "drop.log", false, true},
+		{[]string{"file[0-9].txt"}, "file7.txt", false, true},
+		{[]string{"file[!0-9].txt"}, "file7.txt", false, false},
+		{[]string{`\!important`}, "!important", false, true},
+		{[]string{"# comment", ""}, "# comment", false, false},
+	}
+
+	for _, tt := range tests {
+		ps, err := ParsePatterns(tt.patterns)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.patterns, err)
+		}
+		if got, _ := ps.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("%q matching %s (dir %v): expected %v, got %v", tt.patterns, tt.rel, tt.isDir, tt.want, got)
+		}
+	}
+}
+
+func TestPatternsMatchIn(t *testing.T) {
+	ps, err := ParsePatterns([]string{"/out", "/srv/data/*.tmp", "*.bak"})
+	if err != nil {
+		t.Fatalf("failed to parse patterns: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/srv/data/out", true},        // anchored to the target
+		{"/srv/data/sub/out", false},   // not at the top of the target
+		{"/srv/data/x.tmp", true},      // absolute path
+		{"/srv/data/sub/x.tmp", false}, // not in that directory
+		{"/srv/data/sub/x.bak", true},  // name at any depth
+		{"/srv/data", false},           // the target itself
+	}
+	for _, tt := range tests {
+		if got, _ := ps.MatchIn("/srv/data", tt.path, true); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.want, got)
+		}
+	}
+
+	// Including a directory includes everything beneath it
+	include, _ := ParsePatterns([]string{"assets/"})
+	if !include.MatchesUnder("/srv", "/srv/assets/img/a.png", false) {
+		t.Errorf("expected a file under an included directory to match")
+	}
+	if include.MatchesUnder("/srv", "/srv/src/a.go", false) {
+		t.Errorf("expected a file outside the included directory not to match")
+	}
+}
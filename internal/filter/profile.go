@@ -0,0 +1,199 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Profile describes the build and cache artifacts of an ecosystem. An
+// artifact only matches when the directory containing it also holds one
+// of the manifests, so a "target" directory is only taken for a Rust
+// project if a Cargo.toml sits next to it.
+type Profile struct {
+	Name      string
+	Manifests []string // Names or globs of entries marking a project, e.g. package.json
+	Artifacts []string // Names or globs of artifacts; a trailing "/" only matches directories
+}
+
+// BuiltinProfiles are the profiles available without configuration
+var BuiltinProfiles = []Profile{
+	{"node", []string{"package.json"}, []string{"node_modules/", ".next/", ".nuxt/", ".svelte-kit/", ".parcel-cache/", ".turbo/", "dist/", "coverage/", ".nyc_output/"}},
+	{"python", []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "*.py"}, []string{"__pycache__/", ".pytest_cache/", ".mypy_cache/", ".ruff_cache/", ".tox/", ".nox/", "*.egg-info/", "htmlcov/", ".coverage"}},
+	{"rust", []string{"Cargo.toml"}, []string{"target/"}},
+	{"gradle", []string{"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"}, []string{".gradle/", "build/"}},
+	{"maven", []string{"pom.xml"}, []string{"target/"}},
+	{"xcode", []string{"*.xcodeproj", "*.xcworkspace"}, []string{"DerivedData/", "build/"}},
+	{"swift", []string{"Package.swift"}, []string{".build/", ".swiftpm/"}},
+	{"dotnet", []string{"*.csproj", "*.fsproj", "*.vbproj", "*.sln"}, []string{"bin/", "obj/"}},
+	{"terraform", []string{"*.tf"}, []string{".terraform/"}},
+	{"elixir", []string{"mix.exs"}, []string{"_build/", "deps/", "cover/"}},
+}
+
+// LookupProfiles resolves a comma separated list of profile names. Custom
+// profiles take precedence over built-in ones of the same name.
+func LookupProfiles(names string, custom []Profile) ([]Profile, error) {
+	available := make(map[string]Profile)
+	for _, p := range BuiltinProfiles {
+		available[p.Name] = p
+	}
+	for _, p := range custom {
+		available[p.Name] = p
+	}
+
+	var profiles []Profile
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		p, ok := available[name]
+		if !ok {
+			known := make([]string, 0, len(available))
+			for n := range available {
+				known = append(known, n)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(known, ", "))
+		}
+		if len(p.Manifests) == 0 || len(p.Artifacts) == 0 {
+			return nil, fmt.Errorf("profile %q needs both manifests and artifacts", name)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// ArtifactNames returns the plain names, without globs, of the artifacts
+// of the profiles
+func ArtifactNames(profiles []Profile) []string {
+	var names []string
+	for _, p := range profiles {
+		for _, a := range p.Artifacts {
+			if a = strings.TrimSuffix(a, "/"); !strings.ContainsAny(a, "*?[") {
+				names = append(names, a)
+			}
+		}
+	}
+	return names
+}
+
+// Marks reports whether a directory holding entries with the given names
+// holds one of the profile's manifests. Globs such as *.py merely show that
+// there is source code nearby: they always count for profiles without plain
+// manifest names, and for the others only with withGlobs. Artifacts are
+// gated with globs, so __pycache__ is taken beside any .py file, while
+// project roots are found without them, so a folder of scripts is not a
+// project.
+func (p Profile) Marks(names []string, withGlobs bool) bool {
+	plain := false
+	for _, m := range p.Manifests {
+		if !strings.ContainsAny(m, "*?[") {
+			plain = true
+		}
+	}
+	for _, m := range p.Manifests {
+		if plain && !withGlobs && strings.ContainsAny(m, "*?[") {
+			continue
+		}
+		for _, name := range names {
+			if matched, _ := path.Match(m, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Profiles selects the artifacts of a set of profiles, together with
+// everything beneath them
+type Profiles struct {
+	list []Profile
+
+	mu    sync.Mutex
+	names map[string][]string // Entry names of each directory read so far
+}
+
+// NewProfiles creates a matcher for the profiles
+func NewProfiles(list []Profile) *Profiles {
+	return &Profiles{list: list, names: make(map[string][]string)}
+}
+
+// Names returns the names of the profiles
+func (ps *Profiles) Names() []string {
+	names := make([]string, 0, len(ps.list))
+	for _, p := range ps.list {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Artifact returns the name of the profile whose artifact an entry is,
+// or "" if it is none
+func (ps *Profiles) Artifact(path string, isDir bool) string {
+	return ps.match(filepath.Clean(path), isDir)
+}
+
+// selects reports whether path, or one of its directories up to and
+// including the scan target root, is an artifact
+func (ps *Profiles) selects(root, path string, isDir bool) bool {
+	_, _, ok := ps.artifact(root, path, isDir)
+	return ok
+}
+
+// artifact returns the profile and artifact directory or file that path
+// belongs to
+func (ps *Profiles) artifact(root, p string, isDir bool) (profile, artifact string, ok bool) {
+	root, p = filepath.Clean(root), filepath.Clean(p)
+	if !within(root, p) {
+		return "", "", false
+	}
+	// The target itself may be an artifact, e.g. nuke --profile=node app/node_modules
+	for entry, dir := p, isDir; ; entry, dir = filepath.Dir(entry), true {
+		if name := ps.match(entry, dir); name != "" {
+			return name, entry, true
+		}
+		if entry == root || entry == filepath.Dir(entry) {
+			return "", "", false
+		}
+	}
+}
+
+// match returns the name of the first profile one of whose artifacts
+// entry is, or ""
+func (ps *Profiles) match(entry string, isDir bool) string {
+	name := filepath.Base(entry)
+	for _, p := range ps.list {
+		for _, a := range p.Artifacts {
+			dirOnly := strings.HasSuffix(a, "/")
+			if dirOnly && !isDir {
+				continue
+			}
+			if matched, _ := path.Match(strings.TrimSuffix(a, "/"), name); matched && ps.hasManifest(filepath.Dir(entry), p) {
+				return p.Name
+			}
+		}
+	}
+	return ""
+}
+
+// hasManifest reports whether a directory holds one of a profile's
+// manifests, globs included (see Profile.Marks), reading each directory
+// once
+func (ps *Profiles) hasManifest(dir string, p Profile) bool {
+	ps.mu.Lock()
+	names, ok := ps.names[dir]
+	ps.mu.Unlock()
+	if !ok {
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+		}
+		ps.mu.Lock()
+		ps.names[dir] = names
+		ps.mu.Unlock()
+	}
+	return p.Marks(names, true)
+}
@@ -0,0 +1,111 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-filter-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, name := range []string{
+		"web/package.json",
+		"web/node_modules/lib/index.js",
+		"web/src/node_modules/stray.js",
+		"rs/Cargo.toml",
+		"rs/target/debug/app",
+		"docs/target/keep.md",
+		"py/pkg/mod.py",
+		"py/pkg/__pycache__/mod.pyc",
+		"py/.coverage",
+		"py/setup.py",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	profiles, err := LookupProfiles("node, rust,python", nil)
+	if err != nil {
+		t.Fatalf("LookupProfiles failed: %v", err)
+	}
+	opts := Options{Profiles: NewProfiles(profiles)}
+
+	tests := map[string]bool{
+		"web":                           false,
+		"web/package.json":              false,
+		"web/node_modules":              true,
+		"web/node_modules/lib/index.js": true,
+		"web/src/node_modules":          false, // no package.json next to it
+		"rs/target":                     true,
+		"rs/target/debug/app":           true,
+		"docs/target":                   false, // no Cargo.toml next to it
+		"py/pkg/__pycache__":            true,
+		"py/pkg/__pycache__/mod.pyc":    true,
+		"py/pkg/mod.py":                 false,
+		"py/.coverage":                  true,
+	}
+	for name, want := range tests {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		if got := opts.Match(tmpDir, path, info); got != want {
+			t.Errorf("Match(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	// A target that is itself an artifact
+	target := filepath.Join(tmpDir, "web", "node_modules")
+	info, err := os.Lstat(target)
+	if err != nil {
+		t.Fatalf("failed to stat target: %v", err)
+	}
+	if !opts.Match(target, target, info) {
+		t.Error("an artifact target should match")
+	}
+
+	custom := []Profile{{Name: "rust", Manifests: []string{"Cargo.toml"}, Artifacts: []string{"out/"}}}
+	if ps, err := LookupProfiles("rust", custom); err != nil || ps[0].Artifacts[0] != "out/" {
+		t.Errorf("custom profile should override the built-in one, got %v, %v", ps, err)
+	}
+	if _, err := LookupProfiles("node,cobol", nil); err == nil {
+		t.Error("LookupProfiles accepted an unknown profile")
+	}
+}
+
+func TestProfileMarks(t *testing.T) {
+	profiles, err := LookupProfiles("python,terraform", nil)
+	if err != nil {
+		t.Fatalf("LookupProfiles failed: %v", err)
+	}
+	python, terraform := profiles[0], profiles[1]
+
+	tests := []struct {
+		profile   Profile
+		names     []string
+		withGlobs bool
+		want      bool
+	}{
+		{python, []string{"setup.py"}, false, true},
+		{python, []string{"tool.py"}, false, false}, // a folder of scripts is not a project
+		{python, []string{"tool.py"}, true, true},   // but gates __pycache__ beside it
+		{python, []string{"README.md"}, true, false},
+		{terraform, []string{"main.tf"}, false, true}, // globs are all it has
+	}
+	for _, tt := range tests {
+		if got := tt.profile.Marks(tt.names, tt.withGlobs); got != tt.want {
+			t.Errorf("%s.Marks(%v, %v) = %v, want %v", tt.profile.Name, tt.names, tt.withGlobs, got, tt.want)
+		}
+	}
+}
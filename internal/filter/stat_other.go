@@ -0,0 +1,10 @@
+//go:build !unix
+
+package filter
+
+import "os"
+
+// ownerOf reports no owner on platforms without Unix ownership
+func ownerOf(_ os.FileInfo) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,27 @@
+//go:build darwin || freebsd || netbsd
+
+package filter
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// accessTime returns when a file was last read, falling back to its
+// modification time
+func accessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec)) //nolint:unconvert // Field widths differ between platforms
+	}
+	return info.ModTime()
+}
+
+// changeTime returns when a file's inode last changed, falling back to its
+// modification time
+func changeTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(st.Ctimespec.Sec), int64(st.Ctimespec.Nsec)) //nolint:unconvert // Field widths differ between platforms
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || openbsd || dragonfly || solaris || illumos || aix || darwin || freebsd || netbsd)
+
+package filter
+
+import (
+	"os"
+	"time"
+)
+
+// accessTime returns the modification time where access times are not
+// available
+func accessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
+
+// changeTime returns the modification time where change times are not
+// available
+func changeTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
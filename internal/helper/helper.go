@@ -0,0 +1,215 @@
+// Package helper delegates deletions that need elevated rights to a
+// privileged copy of nuke (sudo nuke --helper) over a pipe.
+//
+// The protocol is JSON lines: the client writes an Options line followed by
+// one line per file and closes the pipe; the helper answers with one line
+// per result as files are processed.
+package helper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"nuke/internal/config"
+	"nuke/internal/deleter"
+	"nuke/internal/scanner"
+	"nuke/internal/trash"
+)
+
+// Options tells the helper how to delete the files it receives
+type Options struct {
+	TrashDir string `json:"trash_dir,omitempty"` // Trash base directory; empty for a hard delete
+	Shred    bool   `json:"shred,omitempty"`
+	Force    bool   `json:"force,omitempty"` // Skip protected path checks
+	UID      int    `json:"uid"`             // Owner of new trash entries
+	GID      int    `json:"gid"`
+	Workers  int    `json:"workers,omitempty"`
+
+	OnBusy    deleter.BusyPolicy `json:"on_busy,omitempty"`
+	Attempts  int                `json:"attempts,omitempty"`
+	BackoffMS int64              `json:"backoff_ms,omitempty"`
+}
+
+// item is the wire form of a file to delete
+type item struct {
+	Path  string `json:"path"`
+	IsDir bool   `json:"is_dir,omitempty"`
+	Size  int64  `json:"size"`
+}
+
+// result is the wire form of a deleter.Result
+type result struct {
+	Path       string         `json:"path"`
+	IsDir      bool           `json:"is_dir,omitempty"`
+	Action     deleter.Action `json:"action"`
+	Bytes      int64          `json:"bytes"`
+	TrashID    string         `json:"trash_id,omitempty"`
+	DurationNS int64          `json:"duration_ns"`
+	Error      string         `json:"error,omitempty"`
+	Class      string         `json:"class,omitempty"`
+	Attempts   int            `json:"attempts,omitempty"`
+}
+
+// Serve runs the helper side of the protocol until every received file has
+// been processed. SIGINT and SIGTERM stop it gracefully like a normal run.
+func Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	dec := json.NewDecoder(bufio.NewReader(in))
+
+	var opts Options
+	if err := dec.Decode(&opts); err != nil {
+		return fmt.Errorf("helper: invalid request: %w", err)
+	}
+
+	var files []scanner.FileInfo
+	for {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("helper: invalid request: %w", err)
+		}
+		files = append(files, scanner.FileInfo{Path: it.Path, IsDir: it.IsDir, Size: it.Size})
+	}
+
+	var trashMgr *trash.Manager
+	if opts.TrashDir != "" {
+		var err error
+		trashMgr, err = trash.NewManagerAt(opts.TrashDir)
+		if err != nil {
+			return err
+		}
+		trashMgr.SetOwner(opts.UID, opts.GID)
+	}
+
+	del := deleter.New(opts.Workers, opts.Shred, trashMgr)
+	del.SetRetryPolicy(deleter.RetryPolicy{
+		OnBusy:   opts.OnBusy,
+		Attempts: opts.Attempts,
+		Backoff:  time.Duration(opts.BackoffMS) * time.Millisecond,
+	})
+
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	var mu sync.Mutex
+	send := func(res deleter.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		r := result{
+			Path:       res.Path,
+			IsDir:      res.IsDir,
+			Action:     res.Action,
+			Bytes:      res.Bytes,
+			TrashID:    res.TrashID,
+			DurationNS: int64(res.Duration),
+			Class:      string(res.Class),
+			Attempts:   res.Attempts,
+		}
+		if res.Err != nil {
+			r.Error = res.Err.Error()
+		}
+		//nolint:errcheck // The client treats missing results as not attempted
+		enc.Encode(r)
+		//nolint:errcheck // See above
+		w.Flush()
+	}
+
+	// Running as root, so protected paths are checked again here
+	cfg := config.LoadConfig()
+	allowed := files[:0]
+	for _, f := range files {
+		if !opts.Force && cfg.IsProtected(f.Path) {
+			send(deleter.Result{Path: f.Path, IsDir: f.IsDir, Action: deleter.ActionSkipped, Err: deleter.ErrProtected, Class: deleter.ClassProtected})
+			continue
+		}
+		allowed = append(allowed, f)
+	}
+
+	del.Delete(ctx, allowed, send)
+	return nil
+}
+
+// Delete starts the helper with argv (e.g. sudo nuke --helper), sends it
+// the files and passes each result to onProgress. The files for which no
+// result was received are returned.
+func Delete(argv []string, opts Options, files []scanner.FileInfo, onProgress deleter.ProgressCallback) ([]scanner.FileInfo, error) {
+	//nolint:gosec // argv is built by the caller from its own executable path
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return files, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return files, err
+	}
+	if err := cmd.Start(); err != nil {
+		return files, fmt.Errorf("failed to start helper: %w", err)
+	}
+
+	// Send the request in the background so results can be read as the
+	// helper produces them
+	go func() {
+		w := bufio.NewWriter(stdin)
+		enc := json.NewEncoder(w)
+		//nolint:errcheck // A helper that exits early is detected by Wait
+		enc.Encode(opts)
+		for _, f := range files {
+			//nolint:errcheck // See above
+			enc.Encode(item{Path: f.Path, IsDir: f.IsDir, Size: f.Size})
+		}
+		//nolint:errcheck // See above
+		w.Flush()
+		_ = stdin.Close()
+	}()
+
+	done := make(map[string]bool, len(files))
+	dec := json.NewDecoder(bufio.NewReader(stdout))
+	for {
+		var r result
+		if err := dec.Decode(&r); err != nil {
+			break
+		}
+		done[r.Path] = true
+		if onProgress == nil {
+			continue
+		}
+		res := deleter.Result{
+			Path:     r.Path,
+			IsDir:    r.IsDir,
+			Action:   r.Action,
+			Bytes:    r.Bytes,
+			TrashID:  r.TrashID,
+			Duration: time.Duration(r.DurationNS),
+			Class:    deleter.ErrorClass(r.Class),
+			Attempts: r.Attempts,
+		}
+		if r.Error != "" {
+			res.Err = errors.New(r.Error)
+		}
+		onProgress(res)
+	}
+
+	waitErr := cmd.Wait()
+
+	var pending []scanner.FileInfo
+	for _, f := range files {
+		if !done[f.Path] {
+			pending = append(pending, f)
+		}
+	}
+	if waitErr != nil {
+		return pending, fmt.Errorf("helper failed: %w", waitErr)
+	}
+	return pending, nil
+}
@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nuke/internal/deleter"
+)
+
+func TestServe(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-helper-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	dir := filepath.Join(tmpDir, "dir")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	// Build a request the way Delete sends it
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+	trashDir := filepath.Join(tmpDir, "trash")
+	_ = enc.Encode(Options{TrashDir: trashDir, Force: true, UID: os.Getuid(), GID: os.Getgid(), Workers: 2})
+	_ = enc.Encode(item{Path: file, Size: 4})
+	_ = enc.Encode(item{Path: dir, IsDir: true})
+
+	var out bytes.Buffer
+	if err := Serve(context.Background(), &in, &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	results := make(map[string]result)
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r result
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid result: %v", err)
+		}
+		results[r.Path] = r
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	for _, path := range []string{file, dir} {
+		r := results[path]
+		if r.Action != deleter.ActionTrashed || r.TrashID == "" {
+			t.Errorf("expected %s to be trashed, got %+v", path, r)
+		}
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected dir to be gone")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(trashDir, "files")); len(entries) != 2 {
+		t.Errorf("expected 2 trash entries, got %d", len(entries))
+	}
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+
+package preflight
+
+import "golang.org/x/sys/unix"
+
+// attributes reads the immutable and append-only file flags (chflags)
+func attributes(path string) (immutable, appendOnly bool) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return false, false
+	}
+	return st.Flags&(unix.UF_IMMUTABLE|unix.SF_IMMUTABLE) != 0, st.Flags&(unix.UF_APPEND|unix.SF_APPEND) != 0
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package preflight
+
+import "golang.org/x/sys/unix"
+
+// Inode flags returned by FS_IOC_GETFLAGS (linux/fs.h)
+const (
+	fsImmutableFL = 0x00000010
+	fsAppendFL    = 0x00000020
+)
+
+// attributes reads the immutable and append-only flags of a regular file
+// or directory. Other file types are never opened, so devices and FIFOs
+// are not touched.
+func attributes(path string) (immutable, appendOnly bool) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return false, false
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFREG && st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		return false, false
+	}
+
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false, false
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	flags, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		// Not supported by this filesystem
+		return false, false
+	}
+	return flags&fsImmutableFL != 0, flags&fsAppendFL != 0
+}
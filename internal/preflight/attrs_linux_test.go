@@ -0,0 +1,72 @@
+//go:build linux
+
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"nuke/internal/scanner"
+)
+
+// changeFlags sets and clears inode flags of a file, as chattr does
+func changeFlags(path string, set, clear uint32) error {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unix.Close(fd) }()
+	flags, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return err
+	}
+	return unix.IoctlSetPointerInt(fd, unix.FS_IOC_SETFLAGS, int(flags&^clear|set))
+}
+
+func TestCheckFileAttributes(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("setting attributes requires root")
+	}
+	tmpDir, err := os.MkdirTemp("", "nuke-preflight-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	var files []scanner.FileInfo
+	for _, name := range []string{"locked", "log", "plain"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		f, err := scanner.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		files = append(files, f)
+		defer func() { _ = changeFlags(path, 0, fsImmutableFL|fsAppendFL) }()
+	}
+
+	locked, log := files[0].Path, files[1].Path
+	if err := changeFlags(locked, fsImmutableFL, 0); err != nil {
+		t.Skipf("filesystem does not support attributes: %v", err)
+	}
+	if err := changeFlags(log, fsAppendFL, 0); err != nil {
+		t.Fatalf("failed to make %s append-only: %v", log, err)
+	}
+
+	// The directory is fine, so only the files' own attributes stop them
+	obstacles := Check(files)
+	if len(obstacles) != 2 {
+		t.Fatalf("expected 2 obstacles, got %+v", obstacles)
+	}
+	if o := obstacles[0]; o.File.Path != locked || o.Kind != KindImmutable || o.Dir != locked || o.NeedsPrivilege() {
+		t.Errorf("unexpected obstacle for the immutable file: %+v", o)
+	}
+	if o := obstacles[1]; o.File.Path != log || o.Kind != KindAppendOnly || o.Dir != log {
+		t.Errorf("unexpected obstacle for the append-only file: %+v", o)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package preflight
+
+// attributes is not supported on this platform
+func attributes(path string) (immutable, appendOnly bool) {
+	return false, false
+}
@@ -0,0 +1,80 @@
+package preflight
+
+import (
+	"os"
+	"sort"
+)
+
+// change records the original mode of a directory made writable by Fix
+type change struct {
+	dir  string
+	mode os.FileMode
+}
+
+// Fixes are the permission changes made by Fix
+type Fixes []change
+
+// Fix gives the owner write and search permission on every fixable parent
+// directory, returning the changes so they can be undone with Restore.
+// Directories that could not be changed are skipped and the first error is
+// returned alongside the changes that were made.
+func Fix(obstacles []Obstacle) (Fixes, error) {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, o := range obstacles {
+		if o.Kind == KindParentNotWritable && o.Fixable && !seen[o.Dir] {
+			seen[o.Dir] = true
+			dirs = append(dirs, o.Dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	var fixes Fixes
+	var firstErr error
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		// Keep the special bits; chmod would otherwise clear them
+		mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		if err := os.Chmod(dir, mode|0300); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		fixes = append(fixes, change{dir: dir, mode: mode})
+	}
+
+	return fixes, firstErr
+}
+
+// Remaining returns the obstacles the fixes did not remove
+func (f Fixes) Remaining(obstacles []Obstacle) []Obstacle {
+	fixed := make(map[string]bool, len(f))
+	for _, c := range f {
+		fixed[c.dir] = true
+	}
+	var remaining []Obstacle
+	for _, o := range obstacles {
+		if o.Kind != KindParentNotWritable || !fixed[o.Dir] {
+			remaining = append(remaining, o)
+		}
+	}
+	return remaining
+}
+
+// Restore puts back the original modes of directories that still exist
+func (f Fixes) Restore() error {
+	var firstErr error
+	for _, c := range f {
+		if err := os.Chmod(c.dir, c.mode); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,187 @@
+// Package preflight checks planned deletions for obstacles before anything
+// is removed
+package preflight
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"nuke/internal/scanner"
+)
+
+// Kind identifies why a planned file cannot be removed
+type Kind string
+
+// Obstacle kinds reported by Check
+const (
+	KindParentNotWritable Kind = "parent-not-writable" // No write/search permission on the parent
+	KindSticky            Kind = "sticky-directory"    // Parent is sticky and owned by someone else
+	KindImmutable         Kind = "immutable"           // File or parent has the immutable attribute
+	KindAppendOnly        Kind = "append-only"         // File or parent has the append-only attribute
+)
+
+// Obstacle is a planned file that cannot be removed by the current user
+type Obstacle struct {
+	File    scanner.FileInfo
+	Kind    Kind
+	Dir     string // Path causing the problem: the parent directory or the file itself
+	Fixable bool   // Dir belongs to the current user, so Fix can make it writable
+}
+
+// Describe returns a short explanation of the obstacle
+func (o Obstacle) Describe() string {
+	switch o.Kind {
+	case KindParentNotWritable:
+		return fmt.Sprintf("parent directory %s is not writable", o.Dir)
+	case KindSticky:
+		return fmt.Sprintf("sticky directory %s and the file belong to another user", o.Dir)
+	case KindImmutable, KindAppendOnly:
+		if o.Dir == o.File.Path {
+			return fmt.Sprintf("%s attribute is set", o.Kind)
+		}
+		return fmt.Sprintf("parent directory %s has the %s attribute", o.Dir, o.Kind)
+	default:
+		return string(o.Kind)
+	}
+}
+
+// NeedsPrivilege reports whether elevated rights would remove the obstacle.
+// Attributes stop root as well and have to be cleared first.
+func (o Obstacle) NeedsPrivilege() bool {
+	return o.Kind == KindParentNotWritable || o.Kind == KindSticky
+}
+
+// dirState caches the checks of a parent directory
+type dirState struct {
+	writable   bool
+	sticky     bool
+	owner      uint32
+	immutable  bool
+	appendOnly bool
+}
+
+// Check examines every planned file and returns those that cannot be
+// removed as the current user. Each directory is examined once. Regular
+// files are opened to read their attributes unless their directory already
+// blocks them.
+func Check(files []scanner.FileInfo) []Obstacle {
+	root := privileged()
+	dirs := make(map[string]*dirState)
+	lookup := func(dir string) *dirState {
+		state, ok := dirs[dir]
+		if !ok {
+			state = inspectDir(dir)
+			if state != nil {
+				state.immutable, state.appendOnly = attributes(dir)
+			}
+			dirs[dir] = state
+		}
+		return state
+	}
+	var obstacles []Obstacle
+
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		state := lookup(dir)
+		if state == nil {
+			// Parent is gone; nothing to check
+			continue
+		}
+
+		var immutable, appendOnly bool
+		switch {
+		case f.IsDir:
+			if own := lookup(f.Path); own != nil {
+				immutable, appendOnly = own.immutable, own.appendOnly
+			}
+		case f.Mode.IsRegular() && !state.immutable && !state.appendOnly:
+			immutable, appendOnly = attributes(f.Path)
+		}
+		switch {
+		case immutable:
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindImmutable, Dir: f.Path})
+		case appendOnly:
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindAppendOnly, Dir: f.Path})
+		case state.immutable:
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindImmutable, Dir: dir})
+		case state.appendOnly:
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindAppendOnly, Dir: dir})
+		case root:
+			// Permissions do not apply
+		case !state.writable:
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindParentNotWritable, Dir: dir, Fixable: ownedByMe(state.owner)})
+		case state.sticky && !ownedByMe(state.owner) && !ownedByMe(ownerOf(f.Path)):
+			obstacles = append(obstacles, Obstacle{File: f, Kind: KindSticky, Dir: dir})
+		}
+	}
+
+	return obstacles
+}
+
+// Only returns the obstacles of the given files, for when just part of the
+// checked files is deleted
+func Only(obstacles []Obstacle, files []scanner.FileInfo) []Obstacle {
+	paths := make(map[string]bool, len(files))
+	for _, f := range files {
+		paths[f.Path] = true
+	}
+	var kept []Obstacle
+	for _, o := range obstacles {
+		if paths[o.File.Path] {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// Attribute returns the attribute kind that prevents path from being
+// removed, if any: set on the path itself or on its parent directory
+func Attribute(path string) Kind {
+	for _, p := range []string{path, filepath.Dir(path)} {
+		immutable, appendOnly := attributes(p)
+		switch {
+		case immutable:
+			return KindImmutable
+		case appendOnly:
+			return KindAppendOnly
+		}
+	}
+	return ""
+}
+
+// Partition splits files into those blocked by an obstacle that elevated
+// rights would remove, together with everything planned beneath them, and
+// the rest
+func Partition(files []scanner.FileInfo, obstacles []Obstacle) (blocked, rest []scanner.FileInfo) {
+	if len(obstacles) == 0 {
+		return nil, files
+	}
+
+	paths := make(map[string]bool, len(obstacles))
+	for _, o := range obstacles {
+		if o.NeedsPrivilege() {
+			paths[o.File.Path] = true
+		}
+	}
+
+	for _, f := range files {
+		if paths[f.Path] || hasBlockedAncestor(f.Path, paths) {
+			blocked = append(blocked, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	return blocked, rest
+}
+
+// hasBlockedAncestor reports whether any parent directory of path is blocked
+func hasBlockedAncestor(path string, paths map[string]bool) bool {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if paths[dir] {
+			return true
+		}
+		if dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
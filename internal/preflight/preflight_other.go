@@ -0,0 +1,24 @@
+//go:build !unix
+
+package preflight
+
+// privileged reports whether the process can ignore directory permissions.
+// Permission pre-checks are only implemented on Unix.
+func privileged() bool {
+	return true
+}
+
+// inspectDir is not supported on this platform
+func inspectDir(dir string) *dirState {
+	return nil
+}
+
+// ownerOf is not supported on this platform
+func ownerOf(path string) uint32 {
+	return 0
+}
+
+// ownedByMe is not supported on this platform
+func ownedByMe(uid uint32) bool {
+	return true
+}
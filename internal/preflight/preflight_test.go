@@ -0,0 +1,77 @@
+package preflight
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nuke/internal/scanner"
+)
+
+func TestPartition(t *testing.T) {
+	files := []scanner.FileInfo{
+		{Path: "/data/locked/a"},
+		{Path: "/data/locked/sub/b"},
+		{Path: "/data/locked/sub", IsDir: true},
+		{Path: "/data/locked", IsDir: true},
+		{Path: "/data/lockedness"},
+		{Path: "/data", IsDir: true},
+	}
+	obstacles := []Obstacle{{File: files[2], Kind: KindParentNotWritable, Dir: "/data/locked"}}
+
+	blocked, rest := Partition(files, obstacles)
+
+	// The blocked directory takes everything beneath it along
+	if len(blocked) != 2 || blocked[0].Path != "/data/locked/sub/b" || blocked[1].Path != "/data/locked/sub" {
+		t.Errorf("unexpected blocked files: %+v", blocked)
+	}
+	if len(rest) != 4 {
+		t.Errorf("expected 4 remaining files, got %d", len(rest))
+	}
+}
+
+func TestFixRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-preflight-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	dir := filepath.Join(tmpDir, "ro")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.Chmod(dir, 0555|os.ModeSticky); err != nil {
+		t.Fatalf("failed to chmod dir: %v", err)
+	}
+	defer func() { _ = os.Chmod(dir, 0755) }()
+
+	obstacles := []Obstacle{
+		{File: scanner.FileInfo{Path: filepath.Join(dir, "a")}, Kind: KindParentNotWritable, Dir: dir, Fixable: true},
+		{File: scanner.FileInfo{Path: filepath.Join(dir, "b")}, Kind: KindParentNotWritable, Dir: dir, Fixable: true},
+		{File: scanner.FileInfo{Path: "/elsewhere/c"}, Kind: KindParentNotWritable, Dir: "/elsewhere"},
+	}
+
+	fixes, err := Fix(obstacles)
+	if err != nil {
+		t.Fatalf("fix failed: %v", err)
+	}
+	if len(fixes) != 1 {
+		t.Fatalf("expected one directory to be fixed, got %d", len(fixes))
+	}
+	info, _ := os.Stat(dir)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected owner write and search, got %v", info.Mode())
+	}
+	if remaining := fixes.Remaining(obstacles); len(remaining) != 1 || remaining[0].Dir != "/elsewhere" {
+		t.Errorf("expected only the unfixed obstacle to remain, got %+v", remaining)
+	}
+
+	if err := fixes.Restore(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	info, _ = os.Stat(dir)
+	if info.Mode().Perm() != 0555 || info.Mode()&os.ModeSticky == 0 {
+		t.Errorf("expected original mode to be restored, got %v", info.Mode())
+	}
+}
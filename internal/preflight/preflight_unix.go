@@ -0,0 +1,42 @@
+//go:build unix
+
+package preflight
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// privileged reports whether the process can ignore directory permissions
+func privileged() bool {
+	return os.Geteuid() == 0
+}
+
+// inspectDir checks the permissions of a parent directory, returning nil
+// if it cannot be examined
+func inspectDir(dir string) *dirState {
+	var st unix.Stat_t
+	if err := unix.Stat(dir, &st); err != nil {
+		return nil
+	}
+	return &dirState{
+		writable: unix.Access(dir, unix.W_OK|unix.X_OK) == nil,
+		sticky:   st.Mode&unix.S_ISVTX != 0,
+		owner:    st.Uid,
+	}
+}
+
+// ownerOf returns the owner of a path without following symlinks
+func ownerOf(path string) uint32 {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return ^uint32(0)
+	}
+	return st.Uid
+}
+
+// ownedByMe reports whether uid is the effective user
+func ownedByMe(uid uint32) bool {
+	return int(uid) == os.Geteuid()
+}
@@ -0,0 +1,310 @@
+// Package projects finds software projects in a workspace and how long
+// they have been inactive
+package projects
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nuke/internal/filter"
+	"nuke/internal/scanner"
+)
+
+// vcsDirs mark the root of a version-controlled project
+var vcsDirs = []string{".git", ".hg", ".svn"}
+
+// scratchExts are extensions of files that change without the project
+// being worked on
+var scratchExts = map[string]bool{".log": true, ".tmp": true, ".swp": true, ".bak": true}
+
+// Artifact is a rebuildable directory or file of a project
+type Artifact struct {
+	Path    string        // Absolute path of the artifact
+	Profile string        // Profile the artifact belongs to
+	Usage   scanner.Usage // Space the artifact takes
+}
+
+// Project is a project root and what it holds
+type Project struct {
+	Path         string     // Absolute path of the project root
+	Kinds        []string   // VCS and profiles marking the project, e.g. git, node
+	LastActivity time.Time  // Last commit or source file change
+	ActiveFile   string     // Source file changed last, empty if a commit was later
+	Artifacts    []Artifact // Rebuildable artifacts found in the project
+}
+
+// Reclaimable returns the space taken by the artifacts of the project
+func (p Project) Reclaimable() scanner.Usage {
+	var total scanner.Usage
+	for _, a := range p.Artifacts {
+		total.Files += a.Usage.Files
+		total.Dirs += a.Usage.Dirs
+		total.Apparent += a.Usage.Apparent
+		total.Allocated += a.Usage.Allocated
+	}
+	return total
+}
+
+// Options configures Find
+type Options struct {
+	Profiles []filter.Profile        // Ecosystems whose manifests and artifacts are known
+	Workers  int                     // Projects analyzed concurrently
+	OnError  func(scanner.ScanError) // Called for paths that could not be read
+}
+
+// Find looks for project roots beneath the given directories: directories
+// holding version control metadata or a manifest of one of the profiles.
+// Projects nested in another belong to the outer one. Each project's last
+// activity comes from its last commit and the modification times of its
+// source files: the files git tracks, or in other projects those that are
+// not hidden, logs or temporary files. Artifacts never count, as builds
+// touch them without the project being worked on.
+func Find(ctx context.Context, roots []string, opts Options) []Project {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 8
+	}
+	var mu sync.Mutex
+	onError := func(path string, err error) {
+		if opts.OnError != nil {
+			mu.Lock()
+			opts.OnError(scanner.ScanError{Path: path, Err: err})
+			mu.Unlock()
+		}
+	}
+
+	var found []Project
+	for _, root := range roots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			onError(root, err)
+			continue
+		}
+		err = filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				onError(path, err)
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != absRoot && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				onError(path, err)
+				return filepath.SkipDir
+			}
+			if kinds := projectKinds(entries, opts.Profiles); len(kinds) > 0 {
+				found = append(found, Project{Path: path, Kinds: kinds})
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			onError(absRoot, err)
+		}
+	}
+
+	// Analyze the projects concurrently
+	profiles := filter.NewProfiles(opts.Profiles)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				analyze(ctx, &found[i], profiles, onError)
+			}
+		}()
+	}
+	for i := range found {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return found
+}
+
+// projectKinds returns what marks a directory with the given entries as a
+// project root
+func projectKinds(entries []os.DirEntry, profiles []filter.Profile) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	var kinds []string
+	for _, vcs := range vcsDirs {
+		for _, name := range names {
+			if name == vcs {
+				kinds = append(kinds, strings.TrimPrefix(vcs, "."))
+			}
+		}
+	}
+	for _, p := range profiles {
+		if p.Marks(names, false) {
+			kinds = append(kinds, p.Name)
+		}
+	}
+	return kinds
+}
+
+// analyze finds the artifacts and the last activity of a project
+func analyze(ctx context.Context, p *Project, profiles *filter.Profiles, onError func(string, error)) {
+	var tracked *filter.Tracked
+	for _, kind := range p.Kinds {
+		if kind == "git" {
+			// Without a readable index, fall back to guessing
+			tracked, _ = filter.GitTracked(p.Path)
+		}
+	}
+
+	_ = filepath.WalkDir(p.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			onError(path, err)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() && isVCSDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if name := profiles.Artifact(path, d.IsDir()); name != "" && path != p.Path {
+			p.Artifacts = append(p.Artifacts, Artifact{Path: path, Profile: name, Usage: usageOf(ctx, path)})
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		hidden := path != p.Path && strings.HasPrefix(d.Name(), ".")
+		if d.IsDir() && hidden && tracked == nil {
+			// IDE state, caches and the like
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if tracked != nil && !tracked.Has(path, false) {
+			return nil
+		}
+		if tracked == nil && (hidden || scratchExts[strings.ToLower(filepath.Ext(path))]) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			onError(path, err)
+			return nil
+		}
+		if info.ModTime().After(p.LastActivity) {
+			p.LastActivity = info.ModTime()
+			p.ActiveFile = path
+		}
+		return nil
+	})
+
+	for _, kind := range p.Kinds {
+		if kind == "git" {
+			if commit, ok := lastCommit(p.Path); ok && commit.After(p.LastActivity) {
+				p.LastActivity = commit
+				p.ActiveFile = ""
+			}
+		}
+	}
+}
+
+// isVCSDir reports whether a directory name is version control metadata
+func isVCSDir(name string) bool {
+	for _, vcs := range vcsDirs {
+		if name == vcs {
+			return true
+		}
+	}
+	return false
+}
+
+// usageOf totals the space an artifact takes
+func usageOf(ctx context.Context, path string) scanner.Usage {
+	var usage scanner.Usage
+	err := scanner.ScanWithCallback(ctx, path, scanner.Options{
+		OnUsage: func(u scanner.Usage) { usage = u },
+	}, func(f scanner.FileInfo) {
+		// A file artifact is reported without a usage callback
+		if !f.IsDir {
+			usage = scanner.Usage{Files: 1, Apparent: f.Size, Allocated: f.Allocated}
+		}
+	})
+	if err != nil {
+		return scanner.Usage{}
+	}
+	return usage
+}
+
+// lastCommit returns the commit time of HEAD in a git repository, asking
+// git if it is installed and reading the reflog otherwise
+func lastCommit(dir string) (time.Time, bool) {
+	if out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%ct").Output(); err == nil {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			return time.Unix(sec, 0), true
+		}
+	}
+
+	// Each reflog line ends in "<name> <email> <time> <zone>\t<message>";
+	// the last one is the latest change of HEAD
+	file, err := os.Open(filepath.Join(dir, ".git", "logs", "HEAD"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = file.Close() }()
+
+	var last string
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		last = s.Text()
+	}
+	header, _, _ := strings.Cut(last, "\t")
+	fields := strings.Fields(header)
+	if len(fields) < 2 {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// Sort orders projects by "size" (most reclaimable first), "age" (longest
+// inactive first) or "name"
+func Sort(projects []Project, by string) error {
+	var less func(a, b Project) bool
+	switch by {
+	case "size":
+		less = func(a, b Project) bool { return a.Reclaimable().Allocated > b.Reclaimable().Allocated }
+	case "age":
+		less = func(a, b Project) bool { return a.LastActivity.Before(b.LastActivity) }
+	case "name":
+		less = func(a, b Project) bool { return a.Path < b.Path }
+	default:
+		return fmt.Errorf("unknown sort order %q (use size, age or name)", by)
+	}
+	sort.SliceStable(projects, func(i, j int) bool { return less(projects[i], projects[j]) })
+	return nil
+}
@@ -0,0 +1,126 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nuke/internal/filter"
+)
+
+func TestFind(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-projects-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	old := time.Now().Add(-200 * 24 * time.Hour)
+	files := map[string]time.Time{
+		"web/package.json":             old,
+		"web/src/index.js":             old,
+		"web/node_modules/lib/a.js":    time.Now(), // artifacts do not count as activity
+		"web/debug.log":                time.Now(), // neither do logs,
+		"web/.env":                     time.Now(), // hidden files
+		"web/.idea/workspace.xml":      time.Now(), // and hidden directories
+		"web/packages/ui/package.json": old,
+		"rs/Cargo.toml":                time.Now(),
+		"rs/target/debug/app":          old,
+		"notes/todo.txt":               old, // no marker, not a project
+		"scripts/tool.py":              old, // source files alone do not make a project
+	}
+	for name, mtime := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("failed to set times: %v", err)
+		}
+	}
+
+	found := Find(context.Background(), []string{tmpDir}, Options{Profiles: filter.BuiltinProfiles, Workers: 2})
+	if err := Sort(found, "name"); err != nil {
+		t.Fatalf("Sort failed: %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("found %d projects, want 2: %+v", len(found), found)
+	}
+
+	rs, web := found[0], found[1]
+	if rs.Path != filepath.Join(tmpDir, "rs") || web.Path != filepath.Join(tmpDir, "web") {
+		t.Fatalf("found %s and %s", rs.Path, web.Path)
+	}
+	if time.Since(rs.LastActivity) > time.Hour {
+		t.Errorf("rs last activity = %v, want recent", rs.LastActivity)
+	}
+	if time.Since(web.LastActivity) < 199*24*time.Hour {
+		t.Errorf("web last activity = %v, want 200 days ago", web.LastActivity)
+	}
+	if len(web.Artifacts) != 1 || web.Artifacts[0].Path != filepath.Join(tmpDir, "web", "node_modules") || web.Artifacts[0].Profile != "node" {
+		t.Errorf("web artifacts = %+v", web.Artifacts)
+	}
+	if web.Reclaimable().Apparent < 4 {
+		t.Errorf("web reclaimable = %+v", web.Reclaimable())
+	}
+	if len(rs.Artifacts) != 1 || rs.Artifacts[0].Profile != "rust" {
+		t.Errorf("rs artifacts = %+v", rs.Artifacts)
+	}
+
+	if err := Sort(found, "age"); err != nil || found[0].Path != web.Path {
+		t.Errorf("sorting by age should put the oldest project first")
+	}
+	if err := Sort(found, "color"); err == nil {
+		t.Error("Sort accepted an unknown order")
+	}
+}
+
+func TestFindTrackedActivity(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	tmpDir, err := os.MkdirTemp("", "nuke-projects-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// Only main.go is tracked; nothing is committed, so files decide
+	old := time.Now().Add(-200 * 24 * time.Hour)
+	files := map[string]time.Time{
+		"main.go":     old,
+		"scratch.go":  time.Now(),
+		"out/run.txt": time.Now(),
+	}
+	for name, mtime := range files {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("failed to set times: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "main.go"}} {
+		if out, err := exec.Command("git", append([]string{"-C", tmpDir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	found := Find(context.Background(), []string{tmpDir}, Options{Workers: 1})
+	if len(found) != 1 {
+		t.Fatalf("found %d projects, want 1: %+v", len(found), found)
+	}
+	if p := found[0]; p.ActiveFile != filepath.Join(tmpDir, "main.go") || time.Since(p.LastActivity) < 199*24*time.Hour {
+		t.Errorf("last activity = %v in %q, want main.go 200 days ago", p.LastActivity, p.ActiveFile)
+	}
+}
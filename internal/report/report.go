@@ -0,0 +1,254 @@
+// Package report records and summarizes per-file deletion results for nuke
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"nuke/internal/deleter"
+)
+
+// record is the serialized form of a deleter.Result
+type record struct {
+	Path       string  `json:"path"`
+	IsDir      bool    `json:"is_dir"`
+	Action     string  `json:"action"`
+	Bytes      int64   `json:"bytes"`
+	TrashID    string  `json:"trash_id,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+	ErrorClass string  `json:"error_class,omitempty"`
+	Attempts   int     `json:"attempts,omitempty"`
+}
+
+// csvHeader lists the CSV columns in record order
+var csvHeader = []string{"path", "is_dir", "action", "bytes", "trash_id", "duration_ms", "error", "error_class", "attempts"}
+
+// Writer streams deletion results to a JSON or CSV report file
+type Writer struct {
+	mu    sync.Mutex
+	file  *os.File
+	buf   *bufio.Writer
+	csv   *csv.Writer // Set for CSV reports
+	count int         // Records written so far (JSON separator handling)
+}
+
+// Create opens a report file. Files ending in .csv are written as CSV;
+// anything else is written as a JSON array.
+func Create(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	w := &Writer{file: f, buf: bufio.NewWriter(f)}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		w.csv = csv.NewWriter(w.buf)
+		if err := w.csv.Write(csvHeader); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return w, nil
+	}
+
+	if _, err := w.buf.WriteString("[\n"); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends a result to the report
+func (w *Writer) Write(r deleter.Result) error {
+	rec := record{
+		Path:       r.Path,
+		IsDir:      r.IsDir,
+		Action:     string(r.Action),
+		Bytes:      r.Bytes,
+		TrashID:    r.TrashID,
+		DurationMS: float64(r.Duration.Microseconds()) / 1000,
+		ErrorClass: string(r.Class),
+		Attempts:   r.Attempts,
+	}
+	if r.Err != nil {
+		rec.Error = r.Err.Error()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.csv != nil {
+		return w.csv.Write([]string{
+			rec.Path,
+			strconv.FormatBool(rec.IsDir),
+			rec.Action,
+			strconv.FormatInt(rec.Bytes, 10),
+			rec.TrashID,
+			strconv.FormatFloat(rec.DurationMS, 'f', 3, 64),
+			rec.Error,
+			rec.ErrorClass,
+			strconv.Itoa(rec.Attempts),
+		})
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if w.count > 0 {
+		if _, err := w.buf.WriteString(",\n"); err != nil {
+			return err
+		}
+	}
+	w.count++
+	_, err = w.buf.Write(append([]byte("  "), data...))
+	return err
+}
+
+// Close finishes and closes the report file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			_ = w.file.Close()
+			return err
+		}
+	} else if _, err := w.buf.WriteString("\n]\n"); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Summary aggregates deletion results for display
+type Summary struct {
+	mu      sync.Mutex
+	actions map[deleter.Action]int
+	bytes   int64
+	retried int                                   // Results that needed more than one attempt
+	healed  int                                   // Retried results that eventually succeeded
+	errors  map[deleter.ErrorClass]map[string]int // class -> directory -> count
+}
+
+// ErrorGroup counts the errors of one class, broken down by directory
+type ErrorGroup struct {
+	Class deleter.ErrorClass
+	Count int
+	Dirs  []DirCount // Sorted by count, largest first
+}
+
+// DirCount is the number of errors in a single directory
+type DirCount struct {
+	Dir   string
+	Count int
+}
+
+// NewSummary creates an empty summary
+func NewSummary() *Summary {
+	return &Summary{
+		actions: make(map[deleter.Action]int),
+		errors:  make(map[deleter.ErrorClass]map[string]int),
+	}
+}
+
+// Add records a result in the summary
+func (s *Summary) Add(r deleter.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.actions[r.Action]++
+	s.bytes += r.Bytes
+
+	if r.Attempts > 1 {
+		s.retried++
+		if r.Err == nil {
+			s.healed++
+		}
+	}
+
+	if r.Action == deleter.ActionFailed {
+		dirs := s.errors[r.Class]
+		if dirs == nil {
+			dirs = make(map[string]int)
+			s.errors[r.Class] = dirs
+		}
+		dirs[filepath.Dir(r.Path)]++
+	}
+}
+
+// Count returns how many results had the given action
+func (s *Summary) Count(action deleter.Action) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.actions[action]
+}
+
+// Succeeded returns how many results were neither failed nor skipped
+func (s *Summary) Succeeded() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.actions[deleter.ActionTrashed] + s.actions[deleter.ActionShredded] + s.actions[deleter.ActionDeleted]
+}
+
+// Bytes returns the total bytes freed or moved to trash
+func (s *Summary) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bytes
+}
+
+// Retried returns how many results needed more than one attempt and how
+// many of those eventually succeeded
+func (s *Summary) Retried() (retried, succeeded int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retried, s.healed
+}
+
+// ErrorGroups returns errors grouped by class, largest group first
+func (s *Summary) ErrorGroups() []ErrorGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]ErrorGroup, 0, len(s.errors))
+	for class, dirs := range s.errors {
+		group := ErrorGroup{Class: class}
+		for dir, count := range dirs {
+			group.Count += count
+			group.Dirs = append(group.Dirs, DirCount{Dir: dir, Count: count})
+		}
+		sort.Slice(group.Dirs, func(i, j int) bool {
+			if group.Dirs[i].Count != group.Dirs[j].Count {
+				return group.Dirs[i].Count > group.Dirs[j].Count
+			}
+			return group.Dirs[i].Dir < group.Dirs[j].Dir
+		})
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Class < groups[j].Class
+	})
+
+	return groups
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"nuke/internal/deleter"
+)
+
+// results covers every column of a report
+var results = []deleter.Result{
+	{Path: "/w/a.log", Action: deleter.ActionTrashed, Bytes: 10, TrashID: "1_a.log", Duration: 1500 * time.Microsecond},
+	{Path: "/w/b, \"quoted\"", Action: deleter.ActionDeleted, Bytes: 20, Attempts: 3},
+	{Path: "/w/locked", Action: deleter.ActionFailed, Err: syscall.EACCES, Class: deleter.ClassPermission},
+	{Path: "/w", IsDir: true, Action: deleter.ActionSkipped, Err: errors.New("not empty"), Class: deleter.ClassNotEmpty},
+}
+
+func writeReport(t *testing.T, path string) {
+	w, err := Create(path)
+	if err != nil {
+		t.Fatalf("failed to create report: %v", err)
+	}
+	for _, r := range results {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("failed to write result: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close report: %v", err)
+	}
+}
+
+func TestWriterJSON(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-report-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	path := filepath.Join(tmpDir, "report.json")
+	writeReport(t, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var got []record
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, data)
+	}
+	want := []record{
+		{Path: "/w/a.log", Action: "trashed", Bytes: 10, TrashID: "1_a.log", DurationMS: 1.5},
+		{Path: "/w/b, \"quoted\"", Action: "deleted", Bytes: 20, Attempts: 3},
+		{Path: "/w/locked", Action: "failed", Error: syscall.EACCES.Error(), ErrorClass: string(deleter.ClassPermission)},
+		{Path: "/w", IsDir: true, Action: "skipped", Error: "not empty", ErrorClass: string(deleter.ClassNotEmpty)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records = %+v, want %+v", got, want)
+	}
+
+	// A report without results is still an array
+	empty := filepath.Join(tmpDir, "empty.json")
+	w, err := Create(empty)
+	if err != nil {
+		t.Fatalf("failed to create report: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close report: %v", err)
+	}
+	data, _ = os.ReadFile(empty)
+	if err := json.Unmarshal(data, &got); err != nil || len(got) != 0 {
+		t.Errorf("empty report = %q, want an empty array", data)
+	}
+}
+
+func TestWriterCSV(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-report-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	path := filepath.Join(tmpDir, "report.CSV")
+	writeReport(t, path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open report: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("report is not valid CSV: %v", err)
+	}
+	want := [][]string{
+		csvHeader,
+		{"/w/a.log", "false", "trashed", "10", "1_a.log", "1.500", "", "", "0"},
+		{"/w/b, \"quoted\"", "false", "deleted", "20", "", "0.000", "", "", "3"},
+		{"/w/locked", "false", "failed", "0", "", "0.000", syscall.EACCES.Error(), string(deleter.ClassPermission), "0"},
+		{"/w", "true", "skipped", "0", "", "0.000", "not empty", string(deleter.ClassNotEmpty), "0"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestSummaryErrorGroups(t *testing.T) {
+	s := NewSummary()
+	for _, r := range results {
+		s.Add(r)
+	}
+	for _, path := range []string{"/x/1", "/x/2", "/y/3"} {
+		s.Add(deleter.Result{Path: path, Action: deleter.ActionFailed, Err: syscall.EBUSY, Class: deleter.ClassBusy, Attempts: 2})
+	}
+
+	want := []ErrorGroup{
+		{Class: deleter.ClassBusy, Count: 3, Dirs: []DirCount{{Dir: "/x", Count: 2}, {Dir: "/y", Count: 1}}},
+		{Class: deleter.ClassPermission, Count: 1, Dirs: []DirCount{{Dir: "/w", Count: 1}}},
+	}
+	if got := s.ErrorGroups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ErrorGroups = %+v, want %+v", got, want)
+	}
+	if s.Succeeded() != 2 || s.Count(deleter.ActionSkipped) != 1 || s.Bytes() != 30 {
+		t.Errorf("succeeded = %d, skipped = %d, bytes = %d", s.Succeeded(), s.Count(deleter.ActionSkipped), s.Bytes())
+	}
+	if retried, healed := s.Retried(); retried != 4 || healed != 1 {
+		t.Errorf("retried = %d, %d succeeded; want 4, 1 succeeded", retried, healed)
+	}
+}
@@ -0,0 +1,413 @@
+// Package runs persists deletion plans so interrupted runs can be resumed
+package runs
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nuke/internal/scanner"
+)
+
+// Errors returned by Validate
+var (
+	ErrGone    = errors.New("no longer exists")
+	ErrChanged = errors.New("changed since planning")
+)
+
+// flushEvery controls how many completions are buffered before the
+// journal is flushed to disk
+const flushEvery = 1000
+
+// Store manages run files on disk
+type Store struct {
+	dir string // Directory holding one subdirectory per run
+}
+
+// Header describes a recorded run
+type Header struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Shred     bool      `json:"shred"`
+	NoTrash   bool      `json:"no_trash"`
+	Total     int       `json:"total"`
+}
+
+// item is the on-disk form of a planned file
+type item struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Mode    uint32 `json:"mode"`
+	ModTime int64  `json:"mtime"`
+	IsDir   bool   `json:"is_dir,omitempty"`
+	Inode   uint64 `json:"inode,omitempty"`
+}
+
+// Run is an open run whose completions are being journaled
+type Run struct {
+	Header
+	mu      sync.Mutex
+	journal *os.File
+	w       *bufio.Writer
+	pending int
+}
+
+// NewStore creates a store in the default location (~/.nuke-runs)
+func NewStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return NewStoreAt(filepath.Join(homeDir, ".nuke-runs"))
+}
+
+// NewStoreAt creates a store rooted at the given directory
+func NewStoreAt(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create runs directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create records the planned files of a new run and opens its journal
+func (s *Store) Create(files []scanner.FileInfo, shred, noTrash bool) (*Run, error) {
+	hdr := Header{
+		ID:        newID(),
+		CreatedAt: time.Now(),
+		Shred:     shred,
+		NoTrash:   noTrash,
+		Total:     len(files),
+	}
+
+	runDir := filepath.Join(s.dir, hdr.ID)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	if err := writePlan(filepath.Join(runDir, "plan.jsonl"), hdr, files); err != nil {
+		_ = os.RemoveAll(runDir)
+		return nil, err
+	}
+
+	return openRun(runDir, hdr)
+}
+
+// Open reopens an existing run so further completions can be journaled
+func (s *Store) Open(id string) (*Run, error) {
+	runDir, err := s.runDir(id)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr, err := readHeader(filepath.Join(runDir, "plan.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	return openRun(runDir, hdr)
+}
+
+// List returns the headers of all recorded runs, newest first
+func (s *Store) List() ([]Header, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []Header
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		hdr, err := readHeader(filepath.Join(s.dir, entry.Name(), "plan.jsonl"))
+		if err != nil {
+			continue
+		}
+		headers = append(headers, hdr)
+	}
+
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].CreatedAt.After(headers[j].CreatedAt)
+	})
+
+	return headers, nil
+}
+
+// Remaining returns the planned files of a run that have not been
+// journaled as completed
+func (s *Store) Remaining(id string) (Header, []scanner.FileInfo, error) {
+	runDir, err := s.runDir(id)
+	if err != nil {
+		return Header{}, nil, err
+	}
+
+	done, err := readJournal(filepath.Join(runDir, "done"))
+	if err != nil {
+		return Header{}, nil, err
+	}
+
+	f, err := os.Open(filepath.Join(runDir, "plan.jsonl"))
+	if err != nil {
+		return Header{}, nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	var hdr Header
+	if err := dec.Decode(&hdr); err != nil {
+		return Header{}, nil, fmt.Errorf("corrupt run file: %w", err)
+	}
+
+	var remaining []scanner.FileInfo
+	for dec.More() {
+		var it item
+		if err := dec.Decode(&it); err != nil {
+			return Header{}, nil, fmt.Errorf("corrupt run file: %w", err)
+		}
+		if done[it.Path] {
+			continue
+		}
+		remaining = append(remaining, scanner.FileInfo{
+			Path:    it.Path,
+			Size:    it.Size,
+			Mode:    os.FileMode(it.Mode),
+			ModTime: it.ModTime,
+			IsDir:   it.IsDir,
+			Inode:   it.Inode,
+		})
+	}
+
+	return hdr, remaining, nil
+}
+
+// Prune removes runs created more than maxAge ago, and run directories
+// that hold no readable plan and are as old, returning how many were
+// removed. A maxAge of zero keeps every run.
+func (s *Store) Prune(maxAge time.Duration) (int, error) {
+	if maxAge <= 0 {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		runDir := filepath.Join(s.dir, entry.Name())
+		var created time.Time
+		if hdr, err := readHeader(filepath.Join(runDir, "plan.jsonl")); err == nil {
+			created = hdr.CreatedAt
+		} else if info, err := entry.Info(); err == nil {
+			created = info.ModTime()
+		} else {
+			continue
+		}
+		if created.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(runDir); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Delete removes a run from the store
+func (s *Store) Delete(id string) error {
+	runDir, err := s.runDir(id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(runDir)
+}
+
+// runDir resolves and checks the directory of a run
+func (s *Store) runDir(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid run id: %q", id)
+	}
+	runDir := filepath.Join(s.dir, id)
+	if _, err := os.Stat(filepath.Join(runDir, "plan.jsonl")); err != nil {
+		return "", fmt.Errorf("run not found: %s", id)
+	}
+	return runDir, nil
+}
+
+// MarkDone journals a path as completed. Paths are written as JSON
+// strings, one per line, so names containing newlines survive.
+func (r *Run) MarkDone(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	r.pending++
+	if r.pending >= flushEvery {
+		r.pending = 0
+		return r.w.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes the journal
+func (r *Run) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		_ = r.journal.Close()
+		return err
+	}
+	return r.journal.Close()
+}
+
+// Validate checks that a planned file is still the one that was planned.
+// Files must match inode and modification time; directories only need to
+// match inode since removing their children updates their mtime.
+func Validate(f scanner.FileInfo) error {
+	current, err := scanner.Stat(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrGone
+		}
+		return err
+	}
+
+	if current.IsDir != f.IsDir {
+		return ErrChanged
+	}
+	if f.Inode != 0 && current.Inode != f.Inode {
+		return ErrChanged
+	}
+	if !f.IsDir && current.ModTime != f.ModTime {
+		return ErrChanged
+	}
+
+	return nil
+}
+
+// openRun opens the completion journal of a run for appending
+func openRun(runDir string, hdr Header) (*Run, error) {
+	journal, err := os.OpenFile(filepath.Join(runDir, "done"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run journal: %w", err)
+	}
+
+	return &Run{
+		Header:  hdr,
+		journal: journal,
+		w:       bufio.NewWriter(journal),
+	}, nil
+}
+
+// writePlan writes the header followed by one JSON line per planned file
+func writePlan(path string, hdr Header, files []scanner.FileInfo) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create run file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	if err := enc.Encode(hdr); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	for _, file := range files {
+		if err := enc.Encode(item{
+			Path:    file.Path,
+			Size:    file.Size,
+			Mode:    uint32(file.Mode),
+			ModTime: file.ModTime,
+			IsDir:   file.IsDir,
+			Inode:   file.Inode,
+		}); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readHeader reads only the first line of a plan file
+func readHeader(path string) (Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Header{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var hdr Header
+	if err := json.NewDecoder(bufio.NewReader(f)).Decode(&hdr); err != nil {
+		return Header{}, fmt.Errorf("corrupt run file: %w", err)
+	}
+	return hdr, nil
+}
+
+// readJournal loads the set of completed paths. A line cut short by a
+// crash ends the journal.
+func readJournal(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return done, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var completed string
+		if err := dec.Decode(&completed); err != nil {
+			if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+				return done, nil
+			}
+			return nil, fmt.Errorf("corrupt run journal: %w", err)
+		}
+		done[completed] = true
+	}
+}
+
+// newID returns a sortable, human-typable run identifier
+func newID() string {
+	var b [3]byte
+	//nolint:errcheck // Random suffix only needs to avoid collisions
+	rand.Read(b[:])
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,149 @@
+package runs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nuke/internal/scanner"
+)
+
+func TestRunResume(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-runs-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	store, err := NewStoreAt(filepath.Join(tmpDir, "runs"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	// Plan three files
+	var files []scanner.FileInfo
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		f, err := scanner.Stat(path)
+		if err != nil {
+			t.Fatalf("failed to stat %s: %v", name, err)
+		}
+		files = append(files, f)
+	}
+
+	run, err := store.Create(files, false, false)
+	if err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	// Complete the first file only
+	if err := os.Remove(files[0].Path); err != nil {
+		t.Fatalf("failed to remove a.txt: %v", err)
+	}
+	if err := run.MarkDone(files[0].Path); err != nil {
+		t.Fatalf("failed to mark done: %v", err)
+	}
+	if err := run.Close(); err != nil {
+		t.Fatalf("failed to close run: %v", err)
+	}
+
+	hdr, remaining, err := store.Remaining(run.ID)
+	if err != nil {
+		t.Fatalf("failed to load remaining: %v", err)
+	}
+	if hdr.Total != 3 {
+		t.Errorf("expected total 3, got %d", hdr.Total)
+	}
+	if len(remaining) != 2 {
+		t.Fatalf("expected 2 remaining files, got %d", len(remaining))
+	}
+
+	// An unchanged file validates; a replaced one does not
+	if err := Validate(remaining[0]); err != nil {
+		t.Errorf("expected b.txt to validate, got %v", err)
+	}
+	if err := os.Remove(remaining[1].Path); err != nil {
+		t.Fatalf("failed to remove c.txt: %v", err)
+	}
+	if err := Validate(remaining[1]); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone for removed file, got %v", err)
+	}
+	if err := os.Mkdir(remaining[1].Path, 0755); err != nil {
+		t.Fatalf("failed to replace c.txt: %v", err)
+	}
+	if err := Validate(remaining[1]); !errors.Is(err, ErrChanged) {
+		t.Errorf("expected ErrChanged for replaced file, got %v", err)
+	}
+
+	// Completed paths may contain newlines
+	odd := filepath.Join(tmpDir, "line\nbreak")
+	reopened, err := store.Open(run.ID)
+	if err != nil {
+		t.Fatalf("failed to reopen run: %v", err)
+	}
+	if err := reopened.MarkDone(odd); err != nil {
+		t.Fatalf("failed to mark done: %v", err)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("failed to close run: %v", err)
+	}
+	done, err := readJournal(filepath.Join(tmpDir, "runs", run.ID, "done"))
+	if err != nil {
+		t.Fatalf("failed to read journal: %v", err)
+	}
+	if len(done) != 2 || !done[files[0].Path] || !done[odd] {
+		t.Errorf("expected a.txt and %q to be done, got %v", odd, done)
+	}
+
+	if err := store.Delete(run.ID); err != nil {
+		t.Fatalf("failed to delete run: %v", err)
+	}
+	if headers, _ := store.List(); len(headers) != 0 {
+		t.Errorf("expected no runs after delete, got %d", len(headers))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-runs-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	store, err := NewStoreAt(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		run, err := store.Create(nil, false, false)
+		if err != nil {
+			t.Fatalf("failed to create run: %v", err)
+		}
+		_ = run.Close()
+		ids = append(ids, run.ID)
+	}
+
+	// Age the first run past the retention period
+	old := Header{ID: ids[0], CreatedAt: time.Now().Add(-48 * time.Hour)}
+	if err := writePlan(filepath.Join(tmpDir, ids[0], "plan.jsonl"), old, nil); err != nil {
+		t.Fatalf("failed to rewrite plan: %v", err)
+	}
+
+	removed, err := store.Prune(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	headers, _ := store.List()
+	if removed != 1 || len(headers) != 1 || headers[0].ID != ids[1] {
+		t.Errorf("expected only %s to be kept, removed %d, left %+v", ids[1], removed, headers)
+	}
+	if removed, _ := store.Prune(0); removed != 0 {
+		t.Errorf("expected no pruning without a retention period, removed %d", removed)
+	}
+}
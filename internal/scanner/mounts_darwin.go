@@ -0,0 +1,32 @@
+//go:build darwin
+
+package scanner
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/unix"
+)
+
+// mountPoints returns the current mount points from getfsstat
+func mountPoints() map[string]bool {
+	n, err := unix.Getfsstat(nil, unix.MNT_NOWAIT)
+	if err != nil || n == 0 {
+		return nil
+	}
+	buf := make([]unix.Statfs_t, n)
+	n, err = unix.Getfsstat(buf, unix.MNT_NOWAIT)
+	if err != nil {
+		return nil
+	}
+
+	mounts := make(map[string]bool, n)
+	for _, fs := range buf[:n] {
+		name := fs.Mntonname[:]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		mounts[string(name)] = true
+	}
+	return mounts
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// mountPoints returns the current mount points from /proc/self/mountinfo.
+// Bind mounts are included even though they share a device number with
+// the filesystem they are mounted on.
+func mountPoints() map[string]bool {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	mounts := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// id parent major:minor root mount-point options ...
+		fields := strings.Fields(sc.Text())
+		if len(fields) > 4 {
+			mounts[unescapeMount(fields[4])] = true
+		}
+	}
+	return mounts
+}
+
+// unescapeMount decodes the octal escapes (\040 for space etc.) used in
+// mountinfo paths
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package scanner
+
+// mountPoints is not supported on this platform; mount points are still
+// detected by a change of device number
+func mountPoints() map[string]bool {
+	return nil
+}
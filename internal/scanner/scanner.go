@@ -2,185 +2,444 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 
 	"nuke/internal/filter"
 )
 
 // FileInfo represents information about a file to be deleted
 type FileInfo struct {
-	Path    string      // Absolute path to the file
-	Size    int64       // Size in bytes
-	Mode    os.FileMode // File mode
-	ModTime int64       // Modification time (Unix timestamp)
-	IsDir   bool        // Whether this is a directory
+	Path      string      // Absolute path to the file
+	Size      int64       // Size in bytes
+	Allocated int64       // Bytes allocated on disk
+	Mode      os.FileMode // File mode
+	ModTime   int64       // Modification time (Unix timestamp)
+	IsDir     bool        // Whether this is a directory
+	Dev       uint64      // Device number (0 where unsupported)
+	Inode     uint64      // Inode number (0 where unsupported)
+	Nlink     uint64      // Number of hard links
+	Root      string      // Scan target this file was found under
 }
 
-// Scan scans a path and returns all matching files
+// newFileInfo builds a FileInfo from the result of an Lstat
+func newFileInfo(root, path string, info os.FileInfo) FileInfo {
+	return FileInfo{
+		Path:      path,
+		Root:      root,
+		Size:      info.Size(),
+		Allocated: allocatedOf(info),
+		Mode:      info.Mode(),
+		ModTime:   info.ModTime().Unix(),
+		IsDir:     info.IsDir(),
+		Dev:       devOf(info),
+		Inode:     inodeOf(info),
+		Nlink:     nlinkOf(info),
+	}
+}
+
+// Stat returns the FileInfo of a single path without following symlinks
+func Stat(path string) (FileInfo, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return newFileInfo(path, path, info), nil
+}
+
+// Options configures a scan
+type Options struct {
+	Recursive bool            // Descend into directories
+	Filter    *filter.Options // Files to report (nil matches everything)
+	Workers   int             // Directories read in parallel (default 8)
+
+	// OneFileSystem keeps the scan on the filesystem of the target, also
+	// when following symlinks
+	OneFileSystem bool
+	// FollowSymlinks descends into symlinked directories. Their contents
+	// are reported beneath the link path and the link itself afterwards;
+	// each directory is only entered once, which also breaks loops.
+	FollowSymlinks bool
+
+	// Protected reports paths that must never be removed. They are not
+	// entered and are passed to OnSkip.
+	Protected func(path string) bool
+
+	// OnSkip is called for every path that was deliberately not entered.
+	// Mount points are never entered. Like unreadable paths, they and the
+	// directories above them are not reported. Directories pruned by the
+	// filter's excludes are left out the same way, without a call.
+	OnSkip func(Skipped)
+
+	// OnUsage is called once for a directory target with the totals of
+	// everything beneath it, whether or not it matches the filters.
+	// Directories that were not entered are not included. A directory
+	// target scanned without Recursive is removed as a whole, so its
+	// reported Size and Allocated are these totals as well.
+	OnUsage func(Usage)
+
+	// OnError is called for every path that could not be read. Anything
+	// beneath such a path is missing from the scan, and the directories
+	// above it are not reported since they cannot be emptied. Calls are
+	// serialized with the callback.
+	OnError func(ScanError)
+}
+
+// Reasons a directory was not entered
+const (
+	SkipMountPoint = "mount point"
+	SkipOtherFS    = "on another filesystem"
+	SkipLoop       = "symlink loop or already scanned"
+	SkipProtected  = "protected"
+)
+
+// Skipped describes a path that was deliberately not entered
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// ScanError describes a path that could not be scanned
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface
+func (e ScanError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e ScanError) Unwrap() error {
+	return e.Err
+}
+
+// Scan scans a path and returns all matching files, deepest first: every
+// directory comes after everything beneath it
 func Scan(path string, recursive bool, filterOpts *filter.Options) ([]FileInfo, error) {
-	absPath, err := filepath.Abs(path)
+	var files []FileInfo
+	err := ScanWithCallback(context.Background(), path, Options{Recursive: recursive, Filter: filterOpts}, func(f FileInfo) {
+		files = append(files, f)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return files, nil
+}
+
+// ScanWithCallback scans a path and calls the callback for each matching
+// file as soon as it is found. Directories are read in parallel, but calls
+// are serialized and in post-order: a directory is only reported after
+// everything beneath it, which is the order files can be deleted in.
+// The walk itself holds only the directories being read; whatever the
+// callback keeps is up to the caller.
+func ScanWithCallback(ctx context.Context, path string, opts Options, callback func(FileInfo)) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
 
 	info, err := os.Lstat(absPath)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var files []FileInfo
+	w := &walker{
+		ctx:      ctx,
+		root:     absPath,
+		opts:     opts,
+		callback: callback,
+	}
 
-	// If it's a file, check filter and return
-	if !info.IsDir() {
-		if filterOpts == nil || filterOpts.Match(absPath, info) {
-			files = append(files, FileInfo{
-				Path:    absPath,
-				Size:    info.Size(),
-				Mode:    info.Mode(),
-				ModTime: info.ModTime().Unix(),
-				IsDir:   false,
-			})
+	// Files are reported as they are. Directories are walked for their
+	// contents, or only to total them up when not recursive.
+	dirInfo := info
+	if opts.FollowSymlinks && info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(absPath); err == nil {
+			dirInfo = target
 		}
-		return files, nil
 	}
+	var usage Usage
+	whole := info.IsDir() && !opts.Recursive
+	if dirInfo.IsDir() && opts.Recursive && opts.Filter.Prune(absPath, absPath) {
+		// An excluded directory target is left alone entirely
+		return nil
+	}
+	if dirInfo.IsDir() && (opts.Recursive || whole) {
+		workers := opts.Workers
+		if workers <= 0 {
+			workers = 8
+		}
+		w.sem = make(chan struct{}, workers)
+		w.rootDev = devOf(dirInfo)
+		w.mounts = mountPoints()
+		w.visited = map[fileID]bool{idOf(dirInfo): true}
+		w.links = make(map[fileID]bool)
+		w.countOnly = whole
 
-	// It's a directory
-	if !recursive {
-		// Non-recursive: just add the directory itself
-		if filterOpts == nil || filterOpts.Match(absPath, info) {
-			files = append(files, FileInfo{
-				Path:    absPath,
-				Size:    info.Size(),
-				Mode:    info.Mode(),
-				ModTime: info.ModTime().Unix(),
-				IsDir:   true,
-			})
+		complete, beneath := w.walkDir(absPath, w.rootDev)
+		usage = w.entryUsage(dirInfo)
+		usage.add(beneath)
+		if ctx.Err() == nil && opts.OnUsage != nil {
+			w.mu.Lock()
+			opts.OnUsage(usage)
+			w.mu.Unlock()
+		}
+		if !complete && !whole {
+			// Partly skipped or unreadable, so the root cannot be emptied
+			return ctx.Err()
 		}
-		return files, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	// Recursive scan
-	err = filepath.Walk(absPath, func(filePath string, fileInfo os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			// Skip files we can't access
-			return nil
+	if w.match(absPath, info) {
+		f := newFileInfo(absPath, absPath, info)
+		if whole {
+			f.Size, f.Allocated = usage.Apparent, usage.Allocated
 		}
+		w.emit(f)
+	}
 
-		// Apply filters
-		if filterOpts != nil && !filterOpts.Match(filePath, fileInfo) {
-			return nil
-		}
+	return nil
+}
 
-		files = append(files, FileInfo{
-			Path:    filePath,
-			Size:    fileInfo.Size(),
-			Mode:    fileInfo.Mode(),
-			ModTime: fileInfo.ModTime().Unix(),
-			IsDir:   fileInfo.IsDir(),
-		})
+// walker reads a directory tree with a bounded number of goroutines
+type walker struct {
+	ctx      context.Context
+	root     string
+	opts     Options
+	sem      chan struct{} // Limits the number of directories read in parallel
+	mu       sync.Mutex    // Serializes callback and OnError calls
+	callback func(FileInfo)
 
-		return nil
-	})
+	rootDev   uint64          // Device of the target
+	mounts    map[string]bool // Mount points (nil where unsupported)
+	countOnly bool            // Only total up usage, reporting nothing
+	seenMu    sync.Mutex
+	visited   map[fileID]bool // Directories entered, when following symlinks
+	links     map[fileID]bool // Hard-linked files already counted
+}
+
+// fileID identifies a directory independently of the path it was reached by
+type fileID struct {
+	dev, ino uint64
+}
+
+// idOf returns the identity of a file
+func idOf(info os.FileInfo) fileID {
+	return fileID{dev: devOf(info), ino: inodeOf(info)}
+}
 
+// walkDir reports everything beneath dir, which is on device dev, and
+// returns once it has all been reported, with the usage of what was found.
+// Subdirectories are handed to other goroutines while slots are free and
+// walked inline otherwise. It returns false if anything beneath dir was
+// skipped or could not be read; such directories are not reported
+// themselves, as they cannot be emptied.
+func (w *walker) walkDir(dir string, dev uint64) (bool, Usage) {
+	var incomplete atomic.Bool
+	var usageMu sync.Mutex
+	var usage Usage
+
+	// Entries read before an error are still reported
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		w.fail(dir, err)
+		incomplete.Store(true)
+	}
+	if !w.countOnly && len(entries) > 0 && w.opts.Filter.AtMaxDepth(w.root, dir) {
+		// Everything beneath is too deep to match, so dir stays as well
+		return false, usage
 	}
 
-	// Sort files so that deeper paths come first (for deletion order)
-	// This ensures we delete files before their parent directories
-	sortByDepth(files)
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		if w.ctx.Err() != nil {
+			break
+		}
 
-	return files, nil
-}
+		path := filepath.Join(dir, e.Name())
+		if !w.countOnly && w.opts.Protected != nil && w.opts.Protected(path) {
+			w.skip(path, SkipProtected)
+			incomplete.Store(true)
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			if !os.IsNotExist(err) {
+				w.fail(path, err)
+				incomplete.Store(true)
+			}
+			// Otherwise removed since it was listed
+			continue
+		}
 
-// sortByDepth sorts files by path depth (deepest first)
-func sortByDepth(files []FileInfo) {
-	// Simple bubble sort for stability - could use sort.Slice for larger sets
-	n := len(files)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			depth1 := countSeparators(files[j].Path)
-			depth2 := countSeparators(files[j+1].Path)
+		// Decide whether to descend, and into what
+		dirInfo := info
+		followed := false
+		if info.Mode()&os.ModeSymlink != 0 && w.opts.FollowSymlinks {
+			if target, err := os.Stat(path); err == nil && target.IsDir() {
+				dirInfo, followed = target, true
+			}
+		}
+		if !dirInfo.IsDir() {
+			usageMu.Lock()
+			usage.add(w.entryUsage(info))
+			usageMu.Unlock()
+			w.report(path, info)
+			continue
+		}
+		if !w.countOnly && w.opts.Filter.Prune(w.root, path) {
+			// Excluded with everything beneath it, so dir stays as well
+			incomplete.Store(true)
+			continue
+		}
+		if reason := w.boundary(path, dirInfo, dev, followed); reason != "" {
+			w.skip(path, reason)
+			incomplete.Store(true)
+			continue
+		}
+		if !w.visit(dirInfo, followed) {
+			// The link itself can still be removed
+			w.skip(path, SkipLoop)
+			usageMu.Lock()
+			usage.add(w.entryUsage(info))
+			usageMu.Unlock()
+			w.report(path, info)
+			continue
+		}
 
-			// Sort by depth descending, then by path ascending for same depth
-			if depth1 < depth2 || (depth1 == depth2 && files[j].Path > files[j+1].Path) {
-				files[j], files[j+1] = files[j+1], files[j]
+		childDev := devOf(dirInfo)
+		task := func() {
+			complete, beneath := w.walkDir(path, childDev)
+			beneath.add(w.entryUsage(info))
+			usageMu.Lock()
+			usage.add(beneath)
+			usageMu.Unlock()
+			if !complete {
+				incomplete.Store(true)
+				return
+			}
+			// A cancelled walk may not have reported everything beneath
+			if w.ctx.Err() == nil {
+				w.report(path, info)
 			}
 		}
+		select {
+		case w.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-w.sem }()
+				task()
+			}()
+		default:
+			task()
+		}
 	}
+	wg.Wait()
+	return !incomplete.Load(), usage
 }
 
-// countSeparators counts the number of path separators in a path
-func countSeparators(path string) int {
-	count := 0
-	for _, c := range path {
-		if c == filepath.Separator {
-			count++
+// entryUsage returns what a single entry adds to a tree's usage. Files
+// with more than one link only count towards the bytes the first time.
+func (w *walker) entryUsage(info os.FileInfo) Usage {
+	if info.IsDir() {
+		return Usage{Dirs: 1, Apparent: info.Size(), Allocated: allocatedOf(info)}
+	}
+	u := Usage{Files: 1}
+	if nlinkOf(info) > 1 {
+		id := idOf(info)
+		w.seenMu.Lock()
+		seen := w.links[id]
+		w.links[id] = true
+		w.seenMu.Unlock()
+		if seen {
+			return u
 		}
 	}
-	return count
+	u.Apparent, u.Allocated = info.Size(), allocatedOf(info)
+	return u
 }
 
-// ScanWithCallback scans a path and calls the callback for each file
-// This is useful for progress reporting during scanning
-func ScanWithCallback(path string, recursive bool, filterOpts *filter.Options, callback func(FileInfo)) error {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return err
+// boundary returns why a directory must not be entered, if it must not.
+// Directories reached through a symlink are not mount points themselves
+// and are only limited by OneFileSystem.
+func (w *walker) boundary(path string, info os.FileInfo, parentDev uint64, followed bool) string {
+	dev := devOf(info)
+	switch {
+	case w.opts.OneFileSystem && dev != w.rootDev:
+		return SkipOtherFS
+	case followed:
+		return ""
+	case w.mounts[path] || dev != parentDev:
+		return SkipMountPoint
+	default:
+		return ""
 	}
+}
 
-	info, err := os.Lstat(absPath)
-	if err != nil {
-		return err
+// visit records a directory when symlinks are followed. Directories are
+// always entered by their real path; through a symlink only if they have
+// not been entered yet, so links to an ancestor never loop.
+func (w *walker) visit(info os.FileInfo, followed bool) bool {
+	if !w.opts.FollowSymlinks {
+		return true
 	}
-
-	// If it's a file, check filter and call callback
-	if !info.IsDir() {
-		if filterOpts == nil || filterOpts.Match(absPath, info) {
-			callback(FileInfo{
-				Path:    absPath,
-				Size:    info.Size(),
-				Mode:    info.Mode(),
-				ModTime: info.ModTime().Unix(),
-				IsDir:   false,
-			})
-		}
-		return nil
+	id := idOf(info)
+	w.seenMu.Lock()
+	defer w.seenMu.Unlock()
+	if followed && w.visited[id] {
+		return false
 	}
+	w.visited[id] = true
+	return true
+}
 
-	// Directory handling
-	if !recursive {
-		if filterOpts == nil || filterOpts.Match(absPath, info) {
-			callback(FileInfo{
-				Path:    absPath,
-				Size:    info.Size(),
-				Mode:    info.Mode(),
-				ModTime: info.ModTime().Unix(),
-				IsDir:   true,
-			})
-		}
-		return nil
+// skip reports a directory that was not entered
+func (w *walker) skip(path, reason string) {
+	if w.opts.OnSkip == nil || w.countOnly {
+		return
 	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.opts.OnSkip(Skipped{Path: path, Reason: reason})
+}
 
-	// Recursive scan
-	return filepath.Walk(absPath, func(filePath string, fileInfo os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return nil
-		}
+// fail reports a path that could not be read
+func (w *walker) fail(path string, err error) {
+	if w.opts.OnError == nil || w.countOnly {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.opts.OnError(ScanError{Path: path, Err: err})
+}
 
-		if filterOpts != nil && !filterOpts.Match(filePath, fileInfo) {
-			return nil
-		}
+// report passes a file to the callback if it matches the filters
+func (w *walker) report(path string, info os.FileInfo) {
+	if w.countOnly || !w.match(path, info) {
+		return
+	}
+	w.emit(newFileInfo(w.root, path, info))
+}
 
-		callback(FileInfo{
-			Path:    filePath,
-			Size:    fileInfo.Size(),
-			Mode:    fileInfo.Mode(),
-			ModTime: fileInfo.ModTime().Unix(),
-			IsDir:   fileInfo.IsDir(),
-		})
+// match applies the filters to a file
+func (w *walker) match(path string, info os.FileInfo) bool {
+	return w.opts.Filter == nil || w.opts.Filter.Match(w.root, path, info)
+}
 
-		return nil
-	})
+// emit passes a file to the callback
+func (w *walker) emit(f FileInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback(f)
 }
@@ -0,0 +1,395 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nuke/internal/filter"
+)
+
+func TestScanPostOrder(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// Wide and deep enough to exercise parallel directory reads
+	for i := 0; i < 20; i++ {
+		dir := filepath.Join(tmpDir, "d"+string(rune('a'+i)), "sub", "deeper")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+		for _, name := range []string{"one", "two"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+		}
+	}
+
+	var files []FileInfo
+	err = ScanWithCallback(context.Background(), tmpDir, Options{Recursive: true, Workers: 4}, func(f FileInfo) {
+		files = append(files, f)
+	})
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// Root + 20 * (3 directories + 2 files)
+	if len(files) != 101 {
+		t.Fatalf("expected 101 entries, got %d", len(files))
+	}
+
+	reported := make(map[string]bool)
+	for _, f := range files {
+		if f.Root != tmpDir {
+			t.Errorf("expected root %s for %s, got %s", tmpDir, f.Path, f.Root)
+		}
+		if reported[filepath.Dir(f.Path)] {
+			t.Errorf("%s reported after its parent directory", f.Path)
+		}
+		reported[f.Path] = true
+	}
+	if files[len(files)-1].Path != tmpDir {
+		t.Errorf("expected the root to be reported last")
+	}
+}
+
+func TestScanFollowSymlinkLoop(t *testing.T) {
+	root, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(root) }()
+
+	if err := os.MkdirAll(filepath.Join(root, "a"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	loop := filepath.Join(root, "a", "loop")
+	if err := os.Symlink(root, loop); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	var skipped []Skipped
+	var paths []string
+	err = ScanWithCallback(context.Background(), root, Options{
+		Recursive:      true,
+		FollowSymlinks: true,
+		OnSkip:         func(s Skipped) { skipped = append(skipped, s) },
+	}, func(f FileInfo) { paths = append(paths, f.Path) })
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(skipped) != 1 || skipped[0].Path != loop || skipped[0].Reason != SkipLoop {
+		t.Fatalf("skipped = %v, want only %s as a loop", skipped, loop)
+	}
+	found := false
+	for _, p := range paths {
+		if p == loop {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("loop link %s was not reported", loop)
+	}
+}
+
+func TestScanUsage(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	sub := filepath.Join(tmpDir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	data := filepath.Join(tmpDir, "data")
+	if err := os.WriteFile(data, make([]byte, 1000), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Link(data, filepath.Join(sub, "link")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	var usage []Usage
+	var files []FileInfo
+	err = ScanWithCallback(context.Background(), tmpDir, Options{
+		OnUsage: func(u Usage) { usage = append(usage, u) },
+	}, func(f FileInfo) { files = append(files, f) })
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(usage) != 1 {
+		t.Fatalf("expected usage once, got %d", len(usage))
+	}
+	u := usage[0]
+	if u.Files != 2 || u.Dirs != 2 {
+		t.Errorf("expected 2 files and 2 directories, got %d and %d", u.Files, u.Dirs)
+	}
+	// The linked data counts once, plus the two directory inodes
+	root, _ := os.Lstat(tmpDir)
+	dir, _ := os.Lstat(sub)
+	if want := 1000 + root.Size() + dir.Size(); u.Apparent != want {
+		t.Errorf("expected %d apparent bytes, got %d", want, u.Apparent)
+	}
+
+	// Without Recursive the target is reported alone with the totals
+	if len(files) != 1 || files[0].Size != u.Apparent || files[0].Allocated != u.Allocated {
+		t.Errorf("expected only the target with the totals, got %+v", files)
+	}
+}
+
+func TestAccount(t *testing.T) {
+	files := []FileInfo{
+		{Path: "/a/plain", Size: 10, Allocated: 4096, Nlink: 1},
+		{Path: "/a/both1", Size: 100, Allocated: 4096, Dev: 1, Inode: 2, Nlink: 2},
+		{Path: "/a/both2", Size: 100, Allocated: 4096, Dev: 1, Inode: 2, Nlink: 2},
+		{Path: "/a/outside", Size: 1000, Allocated: 4096, Dev: 1, Inode: 3, Nlink: 2},
+		{Path: "/a", Size: 4096, Allocated: 4096, IsDir: true, Nlink: 2},
+	}
+
+	space := Account(files)
+	if space.Referenced != 5306 {
+		t.Errorf("expected 5306 bytes referenced, got %d", space.Referenced)
+	}
+	if space.Freed != 4206 {
+		t.Errorf("expected 4206 bytes freed, got %d", space.Freed)
+	}
+	if space.Allocated != 3*4096 {
+		t.Errorf("expected %d bytes allocated, got %d", 3*4096, space.Allocated)
+	}
+	if len(space.Retained) != 1 || space.Retained[0].Path != "/a/outside" {
+		t.Errorf("expected /a/outside to be retained, got %+v", space.Retained)
+	}
+}
+
+func TestScanPrune(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, dir := range []string{"build/obj", "keep/inner", "src"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "file"), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	keep := filepath.Join(tmpDir, "keep")
+
+	exclude, err := filter.ParsePatterns([]string{"build/"})
+	if err != nil {
+		t.Fatalf("failed to parse pattern: %v", err)
+	}
+
+	var skipped []Skipped
+	var files []FileInfo
+	err = ScanWithCallback(context.Background(), tmpDir, Options{
+		Recursive: true,
+		Filter:    &filter.Options{Exclude: exclude},
+		Protected: func(path string) bool { return path == keep },
+		OnSkip:    func(s Skipped) { skipped = append(skipped, s) },
+	}, func(f FileInfo) { files = append(files, f) })
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// Only src is planned; the root holds what was pruned
+	want := map[string]bool{
+		filepath.Join(tmpDir, "src"):         true,
+		filepath.Join(tmpDir, "src", "file"): true,
+	}
+	if len(files) != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), len(files))
+	}
+	for _, f := range files {
+		if !want[f.Path] {
+			t.Errorf("unexpected entry %s", f.Path)
+		}
+	}
+	if len(skipped) != 1 || skipped[0].Path != keep || skipped[0].Reason != SkipProtected {
+		t.Errorf("expected only %s to be skipped as protected, got %v", keep, skipped)
+	}
+}
+
+func TestScanMaxDepth(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, dir := range []string{"full", "empty", "nested/inner"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	for _, name := range []string{"top", "full/file", "nested/inner/file"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	var files []FileInfo
+	err = ScanWithCallback(context.Background(), tmpDir, Options{
+		Recursive: true,
+		Filter:    &filter.Options{MaxDepth: 1},
+	}, func(f FileInfo) { files = append(files, f) })
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// Directories at the limit are planned only when there is nothing
+	// beneath them, as what is there is too deep to match
+	want := map[string]bool{
+		filepath.Join(tmpDir, "top"):   true,
+		filepath.Join(tmpDir, "empty"): true,
+	}
+	if len(files) != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), len(files))
+	}
+	for _, f := range files {
+		if !want[f.Path] {
+			t.Errorf("unexpected entry %s", f.Path)
+		}
+	}
+}
+
+func TestScanBoundary(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, dir := range []string{"mnt", "src"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "file"), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	info, err := os.Stat(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to stat temp dir: %v", err)
+	}
+	dev := devOf(info)
+	mnt := filepath.Join(tmpDir, "mnt")
+	src := filepath.Join(tmpDir, "src")
+
+	// walk scans tmpDir as if it were on rootDev with the given mount points
+	walk := func(opts Options, rootDev uint64, mounts map[string]bool) (bool, map[string]bool, map[string]string) {
+		files := make(map[string]bool)
+		skipped := make(map[string]string)
+		opts.Recursive = true
+		opts.OnSkip = func(s Skipped) { skipped[s.Path] = s.Reason }
+		w := &walker{
+			ctx:      context.Background(),
+			root:     tmpDir,
+			opts:     opts,
+			sem:      make(chan struct{}, 1),
+			callback: func(f FileInfo) { files[f.Path] = true },
+			rootDev:  rootDev,
+			mounts:   mounts,
+			visited:  make(map[fileID]bool),
+			links:    make(map[fileID]bool),
+		}
+		complete, _ := w.walkDir(tmpDir, dev)
+		return complete, files, skipped
+	}
+
+	// Mount points beneath the target are never entered
+	complete, files, skipped := walk(Options{}, dev, map[string]bool{mnt: true})
+	if complete {
+		t.Error("expected the walk to be incomplete with a mount point skipped")
+	}
+	if len(skipped) != 1 || skipped[mnt] != SkipMountPoint {
+		t.Errorf("expected only %s to be skipped as a mount point, got %v", mnt, skipped)
+	}
+	if len(files) != 2 || !files[src] || !files[filepath.Join(src, "file")] {
+		t.Errorf("expected only src and its file, got %v", files)
+	}
+
+	// With --one-file-system nothing on another device is entered
+	complete, files, skipped = walk(Options{OneFileSystem: true}, dev+1, nil)
+	if complete || len(files) != 0 {
+		t.Errorf("expected nothing reported from another filesystem, got %v", files)
+	}
+	if len(skipped) != 2 || skipped[mnt] != SkipOtherFS || skipped[src] != SkipOtherFS {
+		t.Errorf("expected mnt and src to be skipped as on another filesystem, got %v", skipped)
+	}
+
+	// Without it only mount points stop the walk
+	complete, files, _ = walk(Options{}, dev+1, nil)
+	if !complete || len(files) != 4 {
+		t.Errorf("expected everything beneath the target, got %v", files)
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+	tmpDir, err := os.MkdirTemp("", "nuke-scanner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	for _, dir := range []string{"locked", "ok"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, dir, "file"), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	locked := filepath.Join(tmpDir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("failed to chmod dir: %v", err)
+	}
+	defer func() { _ = os.Chmod(locked, 0755) }()
+
+	var scanErrs []ScanError
+	var files []FileInfo
+	err = ScanWithCallback(context.Background(), tmpDir, Options{
+		Recursive: true,
+		OnError:   func(e ScanError) { scanErrs = append(scanErrs, e) },
+	}, func(f FileInfo) { files = append(files, f) })
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	if len(scanErrs) != 1 || scanErrs[0].Path != locked || !errors.Is(scanErrs[0], os.ErrPermission) {
+		t.Fatalf("expected a permission error for %s, got %v", locked, scanErrs)
+	}
+	if !strings.HasPrefix(scanErrs[0].Error(), locked+": ") {
+		t.Errorf("expected the error to name the path, got %q", scanErrs[0].Error())
+	}
+
+	// The unreadable directory and the root above it stay
+	want := map[string]bool{
+		filepath.Join(tmpDir, "ok"):         true,
+		filepath.Join(tmpDir, "ok", "file"): true,
+	}
+	if len(files) != len(want) {
+		t.Errorf("expected %d entries, got %d", len(want), len(files))
+	}
+	for _, f := range files {
+		if !want[f.Path] {
+			t.Errorf("unexpected entry %s", f.Path)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !unix
+
+package scanner
+
+import "os"
+
+// inodeOf returns 0 on platforms without inode numbers
+func inodeOf(_ os.FileInfo) uint64 {
+	return 0
+}
+
+// devOf returns 0 on platforms without device numbers
+func devOf(_ os.FileInfo) uint64 {
+	return 0
+}
+
+// allocatedOf returns the file size where allocation is not reported
+func allocatedOf(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// nlinkOf returns 1 on platforms without hard link counts
+func nlinkOf(_ os.FileInfo) uint64 {
+	return 1
+}
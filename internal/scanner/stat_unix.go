@@ -0,0 +1,40 @@
+//go:build unix
+
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// inodeOf returns the inode number recorded in a FileInfo
+func inodeOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino) //nolint:unconvert // Ino width differs between platforms
+	}
+	return 0
+}
+
+// devOf returns the device number recorded in a FileInfo
+func devOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev) //nolint:unconvert,gosec // Dev width and signedness differ between platforms
+	}
+	return 0
+}
+
+// allocatedOf returns the bytes allocated on disk for a file
+func allocatedOf(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512 //nolint:unconvert // Blocks width differs between platforms
+	}
+	return info.Size()
+}
+
+// nlinkOf returns the number of hard links to a file
+func nlinkOf(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink) //nolint:unconvert // Nlink width differs between platforms
+	}
+	return 1
+}
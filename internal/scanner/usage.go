@@ -0,0 +1,71 @@
+package scanner
+
+import "sort"
+
+// Usage totals the space used by a directory tree. Files with several hard
+// links inside the tree are counted once towards the byte totals, like du.
+type Usage struct {
+	Files     int64 // Entries other than directories, every name counted
+	Dirs      int64 // Directories, including the top one
+	Apparent  int64 // Sum of sizes
+	Allocated int64 // Bytes allocated on disk
+}
+
+// add adds the totals of another tree
+func (u *Usage) add(o Usage) {
+	u.Files += o.Files
+	u.Dirs += o.Dirs
+	u.Apparent += o.Apparent
+	u.Allocated += o.Allocated
+}
+
+// Space describes what deleting a set of files frees
+type Space struct {
+	Referenced int64      // Sum of sizes, every name counted
+	Freed      int64      // Bytes released once all the files are gone
+	Allocated  int64      // On-disk bytes released
+	Retained   []FileInfo // Files whose data stays, as other links remain
+}
+
+// Account totals what deleting files frees. A file with several hard links
+// counts once, and not at all unless every one of its links is among the
+// files; those names are listed as retained.
+func Account(files []FileInfo) Space {
+	var space Space
+	names := make(map[fileID]map[string]FileInfo)
+
+	for _, f := range files {
+		space.Referenced += f.Size
+		if f.IsDir || f.Nlink <= 1 || f.Inode == 0 {
+			space.Freed += f.Size
+			space.Allocated += f.Allocated
+			continue
+		}
+		id := fileID{dev: f.Dev, ino: f.Inode}
+		if names[id] == nil {
+			names[id] = make(map[string]FileInfo)
+		}
+		names[id][f.Path] = f
+	}
+
+	for _, linked := range names {
+		var first FileInfo
+		for _, f := range linked {
+			first = f
+			break
+		}
+		if uint64(len(linked)) >= first.Nlink {
+			space.Freed += first.Size
+			space.Allocated += first.Allocated
+			continue
+		}
+		for _, f := range linked {
+			space.Retained = append(space.Retained, f)
+		}
+	}
+	sort.Slice(space.Retained, func(i, j int) bool {
+		return space.Retained[i].Path < space.Retained[j].Path
+	})
+
+	return space
+}
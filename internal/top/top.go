@@ -0,0 +1,160 @@
+// Package top ranks the entries of a scanned tree by the space they take
+package top
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"nuke/internal/scanner"
+)
+
+// Entry is a file or a directory with everything beneath it
+type Entry struct {
+	Path  string
+	IsDir bool
+	Usage scanner.Usage
+}
+
+// Largest returns the n scanned entries taking the most space on disk,
+// directories totalling everything scanned beneath them. Scan targets
+// themselves are not ranked, and hard links are counted once. A directory
+// holding a single entry is left out, as it takes the same space as that
+// entry and would only crowd the list.
+func Largest(files []scanner.FileInfo, n int) []Entry {
+	dirs := make(map[string]*Entry)
+	children := make(map[string]int)
+	seen := make(map[[2]uint64]bool)
+	var entries []*Entry
+
+	for _, f := range files {
+		root, path := filepath.Clean(f.Root), filepath.Clean(f.Path)
+		if !beneath(root, path) {
+			continue
+		}
+		children[filepath.Dir(path)]++
+
+		var u scanner.Usage
+		if f.IsDir {
+			u.Dirs = 1
+		} else {
+			u.Files = 1
+		}
+		counted := false
+		if !f.IsDir && f.Nlink > 1 && f.Inode != 0 {
+			id := [2]uint64{f.Dev, f.Inode}
+			counted = seen[id]
+			seen[id] = true
+		}
+		if !counted {
+			u.Apparent, u.Allocated = f.Size, f.Allocated
+		}
+
+		// A directory may already be known as the parent of an entry
+		// beneath it
+		e := dirs[path]
+		if e == nil {
+			e = &Entry{Path: path, IsDir: f.IsDir}
+			if f.IsDir {
+				dirs[path] = e
+			}
+			entries = append(entries, e)
+		}
+		add(&e.Usage, u)
+
+		for dir := filepath.Dir(path); beneath(root, dir); dir = filepath.Dir(dir) {
+			d := dirs[dir]
+			if d == nil {
+				d = &Entry{Path: dir, IsDir: true}
+				dirs[dir] = d
+				entries = append(entries, d)
+			}
+			add(&d.Usage, u)
+		}
+	}
+
+	var ranked []Entry
+	for _, e := range entries {
+		if e.IsDir && children[e.Path] == 1 {
+			continue
+		}
+		ranked = append(ranked, *e)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Usage.Allocated != ranked[j].Usage.Allocated {
+			return ranked[i].Usage.Allocated > ranked[j].Usage.Allocated
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// beneath reports whether path is strictly below root
+func beneath(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// add adds one usage to another
+func add(total *scanner.Usage, u scanner.Usage) {
+	total.Files += u.Files
+	total.Dirs += u.Dirs
+	total.Apparent += u.Apparent
+	total.Allocated += u.Allocated
+}
+
+// ParseSelection parses entry numbers and ranges such as "1 3-5,8" for a
+// list of count entries, returning zero-based indexes in ascending order
+func ParseSelection(s string, count int) ([]int, error) {
+	selected := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", field)
+			}
+		}
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("selection %q is out of range 1-%d", field, count)
+		}
+		for i := first; i <= last; i++ {
+			selected[i-1] = true
+		}
+	}
+
+	indexes := make([]int, 0, len(selected))
+	for i := range selected {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
+// Outermost drops the paths beneath another path of the list, as
+// deleting a directory already covers them
+func Outermost(paths []string) []string {
+	var kept []string
+	for _, p := range paths {
+		covered := false
+		for _, q := range paths {
+			if beneath(q, p) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,96 @@
+package top
+
+import (
+	"reflect"
+	"testing"
+
+	"nuke/internal/scanner"
+)
+
+func TestLargest(t *testing.T) {
+	root := "/w"
+	files := []scanner.FileInfo{
+		{Path: "/w/a/b/huge", Size: 300, Allocated: 300, Nlink: 1},
+		{Path: "/w/a/b", IsDir: true, Size: 10, Allocated: 10, Nlink: 2},
+		{Path: "/w/a/c", Size: 5, Allocated: 5, Nlink: 1},
+		{Path: "/w/a", IsDir: true, Size: 10, Allocated: 10, Nlink: 3},
+		{Path: "/w/d/x", Size: 100, Allocated: 100, Nlink: 2, Dev: 1, Inode: 7},
+		{Path: "/w/d/xlink", Size: 100, Allocated: 100, Nlink: 2, Dev: 1, Inode: 7},
+		{Path: "/w/d/y", Size: 150, Allocated: 150, Nlink: 1},
+		{Path: "/w/d", IsDir: true, Size: 10, Allocated: 10, Nlink: 2},
+		{Path: "/w/small", Size: 1, Allocated: 1, Nlink: 1},
+		{Path: "/w", IsDir: true, Size: 10, Allocated: 10, Nlink: 4},
+	}
+	for i := range files {
+		files[i].Root = root
+	}
+
+	var got []string
+	for _, e := range Largest(files, 4) {
+		got = append(got, e.Path)
+	}
+	// The target is never listed
+	want := []string{"/w/a", "/w/a/b/huge", "/w/d", "/w/d/y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Largest = %v, want %v", got, want)
+	}
+
+	all := Largest(files, 0)
+	got = nil
+	for _, e := range all {
+		got = append(got, e.Path)
+	}
+	// /w/a/b holds only huge, so it is left out
+	want = []string{"/w/a", "/w/a/b/huge", "/w/d", "/w/d/y", "/w/d/x", "/w/a/c", "/w/small", "/w/d/xlink"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Largest = %v, want %v", got, want)
+	}
+
+	for _, e := range all {
+		switch e.Path {
+		case "/w/d":
+			// The second name of x adds nothing
+			if e.Usage.Allocated != 260 || e.Usage.Files != 3 {
+				t.Errorf("/w/d usage = %+v, want 260 bytes in 3 files", e.Usage)
+			}
+		case "/w/a":
+			if e.Usage.Allocated != 325 || e.Usage.Files != 2 || e.Usage.Dirs != 2 {
+				t.Errorf("/w/a usage = %+v, want 325 bytes in 2 files and 2 dirs", e.Usage)
+			}
+		}
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{"1", []int{0}},
+		{"1 3-5", []int{0, 2, 3, 4}},
+		{"5,1-2, 2", []int{0, 1, 4}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSelection(tt.input, 5)
+		if err != nil {
+			t.Fatalf("ParseSelection(%q) failed: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSelection(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"0", "6", "3-1", "a", "1-x"} {
+		if _, err := ParseSelection(bad, 5); err == nil {
+			t.Errorf("ParseSelection(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestOutermost(t *testing.T) {
+	got := Outermost([]string{"/w/d/x", "/w/d", "/w/dx", "/w/a/b"})
+	want := []string{"/w/d", "/w/dx", "/w/a/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Outermost = %v, want %v", got, want)
+	}
+}
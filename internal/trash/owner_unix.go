@@ -0,0 +1,16 @@
+//go:build unix
+
+package trash
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownerOf returns the user and group owning a file
+func ownerOf(info os.FileInfo) (uid, gid int, ok bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid), true
+	}
+	return 0, 0, false
+}
@@ -14,6 +14,7 @@ import (
 type Manager struct {
 	trashDir string // Path to trash directory
 	metaDir  string // Path to metadata directory
+	uid, gid int    // Owner given to new entries, -1 to leave unchanged
 }
 
 // TrashEntry represents metadata for a trashed file
@@ -23,6 +24,20 @@ type TrashEntry struct {
 	DeletedAt    time.Time `json:"deleted_at"`
 	Size         int64     `json:"size"`
 	IsDir        bool      `json:"is_dir"`
+	Partial      bool      `json:"partial,omitempty"` // Holds a selection of files mirroring OriginalPath
+	Owners       []Owner   `json:"owners,omitempty"`  // Original owners of paths given to the trash owner
+
+	// Original modes of directories moved into a partial entry, by path
+	// relative to it. The copies in the trash stay writable.
+	Modes map[string]os.FileMode `json:"modes,omitempty"`
+}
+
+// Owner is the original owner of a path inside a trash entry, recorded when
+// the entry was given to another user so restoring can give it back
+type Owner struct {
+	Path string `json:"path"` // Path relative to the entry, "." for the entry itself
+	UID  int    `json:"uid"`
+	GID  int    `json:"gid"`
 }
 
 // NewManager creates a new trash manager using the default home directory
@@ -51,20 +66,82 @@ func NewManagerAt(basePath string) (*Manager, error) {
 	return &Manager{
 		trashDir: trashDir,
 		metaDir:  metaDir,
+		uid:      -1,
+		gid:      -1,
 	}, nil
 }
 
+// SetOwner makes new trash entries and their metadata belong to the given
+// user, so a privileged process can trash files on behalf of that user
+func (m *Manager) SetOwner(uid, gid int) {
+	m.uid, m.gid = uid, gid
+}
+
+// chown gives a trashed path and everything beneath it to the configured
+// owner and returns the owners it replaced
+func (m *Manager) chown(path string) ([]Owner, error) {
+	if m.uid < 0 && m.gid < 0 {
+		return nil, nil
+	}
+	var owners []Owner
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		uid, gid, ok := ownerOf(info)
+		if !ok || ((m.uid < 0 || uid == m.uid) && (m.gid < 0 || gid == m.gid)) {
+			return nil
+		}
+		if err := os.Lchown(p, m.uid, m.gid); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		owners = append(owners, Owner{Path: rel, UID: uid, GID: gid})
+		return nil
+	})
+	return owners, err
+}
+
+// restoreOwners gives the paths of a restored entry back to their original
+// owners
+func restoreOwners(path string, owners []Owner) error {
+	for _, o := range owners {
+		if err := os.Lchown(filepath.Join(path, o.Path), o.UID, o.GID); err != nil {
+			return fmt.Errorf("failed to restore original owner (restoring as root may be required): %w", err)
+		}
+	}
+	return nil
+}
+
+// ID returns the identifier of a trash entry (its name inside the trash)
+func (e TrashEntry) ID() string {
+	if e.TrashPath == "" {
+		return ""
+	}
+	return filepath.Base(e.TrashPath)
+}
+
 // MoveToTrash moves a file to the trash directory
 func (m *Manager) MoveToTrash(path string) error {
+	_, err := m.MoveToTrashEntry(path)
+	return err
+}
+
+// MoveToTrashEntry moves a file to the trash directory and returns the
+// metadata entry recorded for it
+func (m *Manager) MoveToTrashEntry(path string) (TrashEntry, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		return err
+		return TrashEntry{}, err
 	}
 
 	// Get file info
 	info, err := os.Lstat(absPath)
 	if err != nil {
-		return err
+		return TrashEntry{}, err
 	}
 
 	// Generate unique trash name
@@ -74,16 +151,8 @@ func (m *Manager) MoveToTrash(path string) error {
 	trashPath := filepath.Join(m.trashDir, trashName)
 
 	// Move file to trash
-	if err := os.Rename(absPath, trashPath); err != nil {
-		// If rename fails (e.g., cross-device), try copy and delete
-		if err := copyPath(absPath, trashPath); err != nil {
-			return fmt.Errorf("failed to move to trash: %w", err)
-		}
-		if err := os.RemoveAll(absPath); err != nil {
-			// Try to clean up the copy
-			_ = os.RemoveAll(trashPath)
-			return fmt.Errorf("failed to remove original: %w", err)
-		}
+	if err := movePath(absPath, trashPath); err != nil {
+		return TrashEntry{}, err
 	}
 
 	// Save metadata
@@ -95,16 +164,48 @@ func (m *Manager) MoveToTrash(path string) error {
 		IsDir:        info.IsDir(),
 	}
 
+	// Ownership is best effort; the file is already safely in the trash.
+	// What was changed is recorded so restoring can undo it.
+	entry.Owners, _ = m.chown(trashPath)
+
 	metaPath := filepath.Join(m.metaDir, trashName+".json")
 	metaData, err := json.MarshalIndent(entry, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to create metadata: %w", err)
+		return TrashEntry{}, fmt.Errorf("failed to create metadata: %w", err)
 	}
 
 	if err := os.WriteFile(metaPath, metaData, 0644); err != nil {
-		return fmt.Errorf("failed to save metadata: %w", err)
+		return TrashEntry{}, fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	_, _ = m.chown(metaPath)
+
+	return entry, nil
+}
+
+// movePath renames src to dst, falling back to copy and delete when the
+// rename is not possible (e.g., cross-device)
+func movePath(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
 	}
 
+	// Copy under a temporary name first so an interrupted copy never
+	// leaves a half-populated entry in the trash.
+	partialPath := dst + ".partial"
+	if err := copyPath(src, partialPath); err != nil {
+		_ = os.RemoveAll(partialPath)
+		return fmt.Errorf("failed to move to trash: %w", err)
+	}
+	if err := os.Rename(partialPath, dst); err != nil {
+		_ = os.RemoveAll(partialPath)
+		return fmt.Errorf("failed to move to trash: %w", err)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		// Try to clean up the copy
+		_ = os.RemoveAll(dst)
+		return fmt.Errorf("failed to remove original: %w", err)
+	}
 	return nil
 }
 
@@ -141,6 +242,15 @@ func (m *Manager) Restore(filename string) error {
 				return fmt.Errorf("trash file no longer exists: %s", trashEntry.TrashPath)
 			}
 
+			// Partial entries are merged back into the original tree
+			if trashEntry.Partial {
+				if err := mergeTree(trashEntry.TrashPath, trashEntry.OriginalPath, trashEntry.Modes); err != nil {
+					return err
+				}
+				_ = os.Remove(metaPath)
+				return nil
+			}
+
 			// Check if original location is available
 			if _, err := os.Stat(trashEntry.OriginalPath); err == nil {
 				return fmt.Errorf("original location already exists: %s", trashEntry.OriginalPath)
@@ -163,7 +273,7 @@ func (m *Manager) Restore(filename string) error {
 			// Remove metadata
 			_ = os.Remove(metaPath)
 
-			return nil
+			return restoreOwners(trashEntry.OriginalPath, trashEntry.Owners)
 		}
 	}
 
@@ -241,6 +351,11 @@ func (m *Manager) Empty() error {
 	return nil
 }
 
+// BaseDir returns the directory holding the trash files and metadata
+func (m *Manager) BaseDir() string {
+	return filepath.Dir(m.trashDir)
+}
+
 // GetTrashDir returns the trash directory path
 func (m *Manager) GetTrashDir() string {
 	return m.trashDir
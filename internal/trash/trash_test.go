@@ -69,3 +69,145 @@ func TestTrashOperations(t *testing.T) {
 		t.Errorf("expected 0 entries after empty, got %d", len(entries))
 	}
 }
+
+func TestTreeEntryRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nuke-trash-tree-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	mgr, err := NewManagerAt(filepath.Join(tmpDir, "trash"))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+
+	// project/{a/b/one.log, a/keep.txt, a/private/three.log, two.log}
+	root := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(filepath.Join(root, "a", "b"), 0755); err != nil {
+		t.Fatalf("failed to create tree: %v", err)
+	}
+	private := filepath.Join(root, "a", "private")
+	if err := os.Mkdir(private, 0700); err != nil {
+		t.Fatalf("failed to create tree: %v", err)
+	}
+	for _, name := range []string{"a/b/one.log", "a/keep.txt", "a/private/three.log", "two.log"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("data"), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	tree, err := mgr.BeginTree(root)
+	if err != nil {
+		t.Fatalf("failed to begin tree entry: %v", err)
+	}
+	for _, name := range []string{"a/b/one.log", "a/b", "a/private/three.log", "a/private", "two.log"} {
+		if err := tree.Move(filepath.Join(root, name)); err != nil {
+			t.Fatalf("failed to move %s: %v", name, err)
+		}
+	}
+	// a still holds keep.txt and must not be moved
+	if err := tree.Move(filepath.Join(root, "a")); err == nil {
+		t.Errorf("expected moving a non-empty directory to fail")
+	}
+	if err := tree.Move(filepath.Join(tmpDir, "elsewhere")); err == nil {
+		t.Errorf("expected moving a path outside the root to fail")
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("failed to close tree entry: %v", err)
+	}
+
+	entries, totalSize, err := mgr.List()
+	if err != nil {
+		t.Fatalf("failed to list trash: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Partial {
+		t.Fatalf("expected a single partial entry, got %+v", entries)
+	}
+	if totalSize != 12 {
+		t.Errorf("expected size 12, got %d", totalSize)
+	}
+	trashed := filepath.Join(entries[0].TrashPath, "a", "b", "one.log")
+	if _, err := os.Stat(trashed); err != nil {
+		t.Errorf("expected relative structure in trash: %v", err)
+	}
+
+	// Restoring merges the files back next to the ones left in place
+	if err := mgr.Restore("project"); err != nil {
+		t.Fatalf("failed to restore tree entry: %v", err)
+	}
+	for _, name := range []string{"a/b/one.log", "a/keep.txt", "a/private/three.log", "two.log"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("expected %s after restore: %v", name, err)
+		}
+	}
+	// Recreated directories get their original modes back
+	info, err := os.Stat(private)
+	if err != nil {
+		t.Fatalf("failed to stat restored directory: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected restored directory mode 0700, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(entries[0].TrashPath); !os.IsNotExist(err) {
+		t.Errorf("expected trash entry to be removed after restore")
+	}
+}
+
+func TestTrashOwnerRestored(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing owners requires root")
+	}
+	tmpDir, err := os.MkdirTemp("", "nuke-trash-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	mgr, err := NewManagerAt(filepath.Join(tmpDir, "trash"))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	mgr.SetOwner(2000, 2000)
+
+	// A directory of the system user 0 holding a file of user 1000
+	dir := filepath.Join(tmpDir, "system")
+	file := filepath.Join(dir, "owned")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	if err := os.Lchown(file, 1000, 1000); err != nil {
+		t.Fatalf("failed to change owner: %v", err)
+	}
+
+	entry, err := mgr.MoveToTrashEntry(dir)
+	if err != nil {
+		t.Fatalf("failed to move to trash: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(entry.TrashPath, "owned"))
+	if err != nil {
+		t.Fatalf("failed to stat trashed file: %v", err)
+	}
+	if uid, _, _ := ownerOf(info); uid != 2000 {
+		t.Errorf("trashed file owned by %d, want 2000", uid)
+	}
+	if len(entry.Owners) != 2 {
+		t.Errorf("recorded owners = %+v, want the directory and the file", entry.Owners)
+	}
+
+	if err := mgr.Restore("system"); err != nil {
+		t.Fatalf("failed to restore: %v", err)
+	}
+	for path, want := range map[string]int{dir: 0, file: 1000} {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatalf("failed to stat restored path: %v", err)
+		}
+		if uid, gid, _ := ownerOf(info); uid != want || gid != want {
+			t.Errorf("%s restored as %d:%d, want %d:%d", path, uid, gid, want, want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package trash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TreeEntry is a single trash entry that collects a selection of files
+// from a directory tree, stored under their paths relative to the root so
+// the selection can be restored as a unit
+type TreeEntry struct {
+	mu       sync.Mutex
+	entry    TrashEntry
+	metaPath string
+	moved    int // Paths moved into the entry so far
+}
+
+// BeginTree creates an empty trash entry for files beneath root. Metadata
+// is written immediately so an interrupted run still leaves a restorable
+// entry behind.
+func (m *Manager) BeginTree(root string) (*TreeEntry, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	trashName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(absRoot))
+	t := &TreeEntry{
+		entry: TrashEntry{
+			OriginalPath: absRoot,
+			TrashPath:    filepath.Join(m.trashDir, trashName),
+			DeletedAt:    time.Now(),
+			IsDir:        true,
+			Partial:      true,
+		},
+		metaPath: filepath.Join(m.metaDir, trashName+".json"),
+	}
+
+	if err := os.Mkdir(t.entry.TrashPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create trash entry: %w", err)
+	}
+	if err := t.save(); err != nil {
+		_ = os.Remove(t.entry.TrashPath)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Entry returns the metadata of the tree entry
+func (t *TreeEntry) Entry() TrashEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.entry
+}
+
+// Move moves a file beneath the root into the entry. Directories are only
+// moved once empty, so unselected contents are never trashed with them.
+func (t *TreeEntry) Move(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(t.entry.OriginalPath, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is not beneath %s", absPath, t.entry.OriginalPath)
+	}
+	dst := filepath.Join(t.entry.TrashPath, rel)
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		if err := os.Remove(absPath); err != nil {
+			return err
+		}
+		// Keep the directory in the entry even if nothing was moved into it
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return fmt.Errorf("failed to move to trash: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to move to trash: %w", err)
+		}
+		if err := movePath(absPath, dst); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	t.moved++
+	if info.IsDir() {
+		if t.entry.Modes == nil {
+			t.entry.Modes = make(map[string]os.FileMode)
+		}
+		t.entry.Modes[filepath.ToSlash(rel)] = info.Mode() & dirModeBits
+	} else {
+		t.entry.Size += info.Size()
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// Close records the final size of the entry, or removes it if nothing was
+// moved into it
+func (t *TreeEntry) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.moved == 0 {
+		_ = os.Remove(t.metaPath)
+		return os.RemoveAll(t.entry.TrashPath)
+	}
+	return t.save()
+}
+
+// save writes the metadata of the entry
+func (t *TreeEntry) save() error {
+	metaData, err := json.MarshalIndent(t.entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to create metadata: %w", err)
+	}
+	if err := os.WriteFile(t.metaPath, metaData, 0644); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+	return nil
+}
+
+// dirModeBits are the parts of a directory mode kept for restoring
+const dirModeBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// mergeTree moves the contents of a partial entry back beneath dst,
+// recreating missing directories with their recorded modes. Paths that
+// already exist at their original location are left in the trash and
+// reported as an error.
+func mergeTree(src, dst string, modes map[string]os.FileMode) error {
+	var conflicts []string
+	var created []string // Relative paths of recreated directories, parents first
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			existing, err := os.Lstat(target)
+			if err == nil {
+				if !existing.IsDir() {
+					conflicts = append(conflicts, target)
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// Created writable so its contents can be moved in; the
+			// recorded mode is applied once they are
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			created = append(created, filepath.ToSlash(rel))
+			return nil
+		}
+
+		if _, err := os.Lstat(target); err == nil {
+			conflicts = append(conflicts, target)
+			return nil
+		}
+		if err := os.Rename(path, target); err != nil {
+			if err := copyPath(path, target); err != nil {
+				return fmt.Errorf("failed to restore file: %w", err)
+			}
+			_ = os.Remove(path)
+		}
+		return nil
+	})
+	// Deepest first, so a read-only parent does not block its children
+	for i := len(created) - 1; i >= 0; i-- {
+		mode, ok := modes[created[i]]
+		if !ok {
+			mode = 0755
+		}
+		if chmodErr := os.Chmod(filepath.Join(dst, created[i]), mode); chmodErr != nil && err == nil {
+			err = fmt.Errorf("failed to restore directory mode: %w", chmodErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d path(s) already exist and were left in trash, e.g. %s", len(conflicts), conflicts[0])
+	}
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,575 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"nuke/internal/scanner"
+	"nuke/internal/utils"
+)
+
+// node is a row of the tree: a planned file or directory, or a directory
+// that only groups planned entries
+type node struct {
+	name     string
+	path     string
+	file     *scanner.FileInfo // Planned entry, nil for grouping directories
+	isDir    bool
+	parent   *node
+	children []*node
+	expanded bool
+	selected bool
+
+	// Planned entries in the subtree, including the node itself
+	totalCount, selCount int
+	totalSize, selSize   int64
+}
+
+// full reports whether everything planned in the subtree is selected
+func (n *node) full() bool {
+	return n.selCount == n.totalCount
+}
+
+// model is the state of the review screen, separate from the terminal so
+// it can be tested
+type model struct {
+	files  []scanner.FileInfo
+	nodes  map[string]*node
+	roots  []*node
+	cursor int
+	top    int // First row shown
+
+	filter    string         // Text rows are filtered by
+	filtering bool           // Whether keys go to the filter
+	matches   map[*node]bool // Rows shown under the filter
+	preview   bool
+	previews  map[string][]string
+}
+
+// action is what the review loop does after a key
+type action int
+
+const (
+	actionNone action = iota
+	actionDone
+	actionCancel
+)
+
+// newModel builds the tree of the planned files, everything selected
+func newModel(files []scanner.FileInfo) *model {
+	m := &model{files: files, nodes: make(map[string]*node), preview: true, previews: make(map[string][]string)}
+	for i := range files {
+		f := &files[i]
+		root := f.Root
+		if root == "" || !within(root, f.Path) {
+			root = f.Path
+		}
+		n := m.nodeFor(filepath.Clean(f.Path), filepath.Clean(root))
+		n.file = f
+		n.isDir = f.IsDir
+		n.selected = true
+		for a := n; a != nil; a = a.parent {
+			a.totalCount++
+			a.selCount++
+			a.totalSize += f.Size
+			a.selSize += f.Size
+		}
+	}
+
+	var sortTree func([]*node)
+	sortTree = func(nodes []*node) {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].totalSize != nodes[j].totalSize {
+				return nodes[i].totalSize > nodes[j].totalSize
+			}
+			return nodes[i].name < nodes[j].name
+		})
+		for _, n := range nodes {
+			sortTree(n.children)
+		}
+	}
+	sortTree(m.roots)
+	for _, r := range m.roots {
+		r.expanded = true
+	}
+	return m
+}
+
+// nodeFor returns the node of a path beneath a scan root, creating it and
+// its directories as needed
+func (m *model) nodeFor(path, root string) *node {
+	if n, ok := m.nodes[path]; ok {
+		return n
+	}
+	n := &node{name: filepath.Base(path), path: path, isDir: true}
+	m.nodes[path] = n
+	if path == root {
+		n.name = path
+		m.roots = append(m.roots, n)
+		return n
+	}
+	n.parent = m.nodeFor(filepath.Dir(path), root)
+	n.parent.children = append(n.parent.children, n)
+	return n
+}
+
+// within reports whether path is root or beneath it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rows returns the nodes shown, in display order
+func (m *model) rows() []*node {
+	var rows []*node
+	var walk func([]*node)
+	walk = func(nodes []*node) {
+		for _, n := range nodes {
+			if m.matches != nil && !m.matches[n] {
+				continue
+			}
+			rows = append(rows, n)
+			// The filter shows every match, collapsed or not
+			if n.expanded || m.matches != nil {
+				walk(n.children)
+			}
+		}
+	}
+	walk(m.roots)
+	return rows
+}
+
+// depth returns how far below its root a node is
+func (n *node) depth() int {
+	d := 0
+	for p := n.parent; p != nil; p = p.parent {
+		d++
+	}
+	return d
+}
+
+// setFilter shows only the rows whose path contains the text, with the
+// directories leading to them
+func (m *model) setFilter(text string) {
+	m.filter = text
+	m.cursor, m.top = 0, 0
+	if text == "" {
+		m.matches = nil
+		return
+	}
+	query := strings.ToLower(text)
+	m.matches = make(map[*node]bool)
+	var mark func(*node) bool
+	mark = func(n *node) bool {
+		shown := strings.Contains(strings.ToLower(n.path), query)
+		for _, c := range n.children {
+			if mark(c) {
+				shown = true
+			}
+		}
+		if shown {
+			m.matches[n] = true
+		}
+		return shown
+	}
+	for _, r := range m.roots {
+		mark(r)
+	}
+}
+
+// setSelected selects or deselects one planned entry, keeping the totals
+// of its directories up to date
+func (m *model) setSelected(n *node, selected bool) {
+	if n.file == nil || n.selected == selected {
+		return
+	}
+	n.selected = selected
+	count, size := 1, n.file.Size
+	if !selected {
+		count, size = -1, -n.file.Size
+	}
+	for a := n; a != nil; a = a.parent {
+		a.selCount += count
+		a.selSize += size
+	}
+}
+
+// toggle selects a node and everything beneath it, or deselects it all if
+// it was fully selected
+func (m *model) toggle(n *node) {
+	selected := !n.full()
+	var walk func(*node)
+	walk = func(n *node) {
+		m.setSelected(n, selected)
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	for a := n.parent; a != nil; a = a.parent {
+		m.settle(a)
+	}
+}
+
+// settle keeps a planned directory consistent with its contents: it cannot
+// be removed while something beneath it stays, and is removed again once
+// everything beneath it is selected. Directories with nothing planned
+// beneath them are selected on their own, like files.
+func (m *model) settle(n *node) {
+	if n.file == nil || n.totalCount == 1 {
+		return
+	}
+	self := 0
+	if n.selected {
+		self = 1
+	}
+	m.setSelected(n, n.selCount-self == n.totalCount-1)
+}
+
+// selectShown selects or deselects every file and empty directory matching
+// the filter, or all of them without one
+func (m *model) selectShown(selected bool) {
+	for _, n := range m.nodes {
+		if n.file == nil || n.totalCount > 1 {
+			continue
+		}
+		if m.matches != nil && !strings.Contains(strings.ToLower(n.path), strings.ToLower(m.filter)) {
+			continue
+		}
+		m.setSelected(n, selected)
+	}
+	// Directories follow their contents, deepest first
+	var dirs []*node
+	for _, n := range m.nodes {
+		if n.totalCount > 1 {
+			dirs = append(dirs, n)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].depth() > dirs[j].depth() })
+	for _, d := range dirs {
+		m.settle(d)
+	}
+}
+
+// selection returns the selected files in the order they were planned
+func (m *model) selection() []scanner.FileInfo {
+	var selected []scanner.FileInfo
+	for _, f := range m.files {
+		if n := m.nodes[filepath.Clean(f.Path)]; n != nil && n.selected {
+			selected = append(selected, f)
+		}
+	}
+	return selected
+}
+
+// totals returns the selected and planned entries and bytes
+func (m *model) totals() (selCount, totalCount int, selSize, totalSize int64) {
+	for _, r := range m.roots {
+		selCount += r.selCount
+		totalCount += r.totalCount
+		selSize += r.selSize
+		totalSize += r.totalSize
+	}
+	return
+}
+
+// handle applies a key and tells the loop what to do next
+func (m *model) handle(k key) action {
+	rows := m.rows()
+	var cur *node
+	if m.cursor < len(rows) {
+		cur = rows[m.cursor]
+	}
+
+	if m.filtering {
+		switch k.name {
+		case "enter":
+			m.filtering = false
+			return actionNone
+		case "esc":
+			m.filtering = false
+			m.setFilter("")
+			return actionNone
+		case "backspace":
+			if m.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(m.filter)
+				m.setFilter(m.filter[:len(m.filter)-size])
+			}
+			return actionNone
+		case "rune":
+			m.setFilter(m.filter + string(k.r))
+			return actionNone
+		case "ctrl-c":
+			return actionCancel
+		}
+	}
+
+	switch k.name {
+	case "up":
+		m.cursor--
+	case "down":
+		m.cursor++
+	case "pgup":
+		m.cursor -= 10
+	case "pgdn":
+		m.cursor += 10
+	case "home":
+		m.cursor = 0
+	case "end":
+		m.cursor = len(rows) - 1
+	case "right":
+		if cur != nil && len(cur.children) > 0 {
+			if !cur.expanded {
+				cur.expanded = true
+			} else {
+				m.cursor++
+			}
+		}
+	case "left":
+		if cur != nil {
+			if cur.expanded && len(cur.children) > 0 && m.matches == nil {
+				cur.expanded = false
+			} else if cur.parent != nil {
+				for i, r := range rows {
+					if r == cur.parent {
+						m.cursor = i
+					}
+				}
+			}
+		}
+	case "enter":
+		if cur != nil && len(cur.children) > 0 {
+			cur.expanded = !cur.expanded
+		}
+	case "esc", "ctrl-c":
+		return actionCancel
+	case "rune":
+		switch k.r {
+		case 'k':
+			return m.handle(key{name: "up"})
+		case 'j':
+			return m.handle(key{name: "down"})
+		case 'h':
+			return m.handle(key{name: "left"})
+		case 'l':
+			return m.handle(key{name: "right"})
+		case 'g':
+			return m.handle(key{name: "home"})
+		case 'G':
+			return m.handle(key{name: "end"})
+		case ' ':
+			if cur != nil {
+				m.toggle(cur)
+				m.cursor++
+			}
+		case 'a':
+			m.selectShown(true)
+		case 'n':
+			m.selectShown(false)
+		case '/':
+			m.filtering = true
+		case 'p':
+			m.preview = !m.preview
+		case 'd':
+			return actionDone
+		case 'q':
+			return actionCancel
+		}
+	}
+
+	if m.cursor >= len(rows) {
+		m.cursor = len(rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	return actionNone
+}
+
+// render draws the whole screen for a terminal of the given size
+func (m *model) render(width, height int) string {
+	rows := m.rows()
+	listHeight := height - 4
+	if listHeight < 1 {
+		listHeight = 1
+	}
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+	if m.cursor >= m.top+listHeight {
+		m.top = m.cursor - listHeight + 1
+	}
+
+	listWidth, previewWidth := width, 0
+	if m.preview && width >= 100 {
+		listWidth = width * 55 / 100
+		previewWidth = width - listWidth - 3
+	}
+	var preview []string
+	if previewWidth > 0 && m.cursor < len(rows) {
+		preview = m.previewOf(rows[m.cursor], listHeight)
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	title := "nuke - review what will be deleted"
+	if m.filter != "" || m.filtering {
+		title += "   filter: " + m.filter
+		if m.filtering {
+			title += "_"
+		}
+	}
+	b.WriteString("\x1b[1m" + fit(title, width) + "\x1b[0m\x1b[K\r\n")
+	b.WriteString(strings.Repeat("─", width) + "\x1b[K\r\n")
+
+	for i := 0; i < listHeight; i++ {
+		line := ""
+		if r := m.top + i; r < len(rows) {
+			line = m.row(rows[r], listWidth)
+			if r == m.cursor {
+				line = "\x1b[7m" + line + "\x1b[0m"
+			}
+		} else {
+			line = strings.Repeat(" ", listWidth)
+		}
+		if previewWidth > 0 {
+			p := ""
+			if i < len(preview) {
+				p = preview[i]
+			}
+			line += " │ " + fit(p, previewWidth)
+		}
+		b.WriteString(line + "\x1b[K\r\n")
+	}
+
+	selCount, totalCount, selSize, totalSize := m.totals()
+	status := fmt.Sprintf("Selected %d of %d entries, %s of %s", selCount, totalCount, utils.FormatSize(selSize), utils.FormatSize(totalSize))
+	b.WriteString("\x1b[1m" + fit(status, width) + "\x1b[0m\x1b[K\r\n")
+	help := "↑↓ move  ←→ collapse/expand  space toggle  a/n all/none  / filter  p preview  d delete selected  q quit"
+	if m.filtering {
+		help = "type to filter  enter keep filter  esc clear filter"
+	}
+	b.WriteString(fit(help, width) + "\x1b[K\x1b[J")
+	return b.String()
+}
+
+// row formats one row of the tree
+func (m *model) row(n *node, width int) string {
+	mark := "[ ]"
+	switch {
+	case n.totalCount == 0:
+		mark = "   "
+	case n.full():
+		mark = "[x]"
+	case n.selCount > 0:
+		mark = "[~]"
+	}
+	arrow := "  "
+	if len(n.children) > 0 {
+		arrow = "▸ "
+		if n.expanded || m.matches != nil {
+			arrow = "▾ "
+		}
+	}
+	name := n.name
+	if n.isDir && !strings.HasSuffix(name, string(filepath.Separator)) {
+		name += string(filepath.Separator)
+	}
+	size := utils.FormatSize(n.totalSize)
+	if !n.full() {
+		size = utils.FormatSize(n.selSize) + " of " + size
+	}
+	left := strings.Repeat("  ", n.depth()) + mark + " " + arrow + name
+	space := width - utf8.RuneCountInString(size) - 1
+	if space < 1 {
+		return fit(left, width)
+	}
+	return fit(left, space) + " " + size
+}
+
+// previewOf describes a node: metadata, then the start of a text file
+func (m *model) previewOf(n *node, height int) []string {
+	lines := []string{n.path, ""}
+	switch {
+	case n.file != nil && !n.isDir:
+		f := n.file
+		lines = append(lines,
+			"Size:     "+utils.FormatSize(f.Size),
+			"Modified: "+time.Unix(f.ModTime, 0).Format("2006-01-02 15:04"),
+			"Mode:     "+f.Mode.String(),
+		)
+		if f.Nlink > 1 {
+			lines = append(lines, fmt.Sprintf("Links:    %d", f.Nlink))
+		}
+		lines = append(lines, "")
+		head, ok := m.previews[n.path]
+		if !ok {
+			head = fileHead(n.path, height)
+			m.previews[n.path] = head
+		}
+		lines = append(lines, head...)
+	default:
+		lines = append(lines,
+			fmt.Sprintf("Planned:  %d entries, %s", n.totalCount, utils.FormatSize(n.totalSize)),
+			fmt.Sprintf("Selected: %d entries, %s", n.selCount, utils.FormatSize(n.selSize)),
+		)
+		if n.file == nil {
+			lines = append(lines, "", "Not deleted itself: only some of its", "contents are planned")
+		}
+	}
+	return lines
+}
+
+// fileHead returns the first lines of a text file, or a note for other
+// files
+func fileHead(path string, maxLines int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return []string{"(cannot read: " + err.Error() + ")"}
+	}
+	defer func() { _ = file.Close() }()
+
+	buf := make([]byte, 4096)
+	n, _ := file.Read(buf)
+	data := buf[:n]
+	if n == 0 {
+		return []string{"(empty)"}
+	}
+	if strings.IndexByte(string(data), 0) >= 0 {
+		return []string{"(binary data)"}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(lines) == maxLines {
+			break
+		}
+		line = strings.ReplaceAll(line, "\t", "    ")
+		lines = append(lines, strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) || r == utf8.RuneError {
+				return -1
+			}
+			return r
+		}, line))
+	}
+	return lines
+}
+
+// fit pads or cuts s to exactly width columns
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
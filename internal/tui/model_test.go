@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"nuke/internal/scanner"
+)
+
+// plan is a recursive deletion of /w, children before their directories
+func plan() []scanner.FileInfo {
+	files := []scanner.FileInfo{
+		{Path: "/w/src/main.go", Size: 10},
+		{Path: "/w/src/util.go", Size: 20},
+		{Path: "/w/src", IsDir: true},
+		{Path: "/w/build/app.log", Size: 300},
+		{Path: "/w/build", IsDir: true},
+		{Path: "/w/README", Size: 5},
+		{Path: "/w", IsDir: true},
+	}
+	for i := range files {
+		files[i].Root = "/w"
+	}
+	return files
+}
+
+func paths(files []scanner.FileInfo) []string {
+	var out []string
+	for _, f := range files {
+		out = append(out, f.Path)
+	}
+	return out
+}
+
+func names(rows []*node) []string {
+	var out []string
+	for _, n := range rows {
+		out = append(out, n.name)
+	}
+	return out
+}
+
+func TestModelTree(t *testing.T) {
+	m := newModel(plan())
+
+	// Only the target is expanded, largest entries first
+	if got, want := names(m.rows()), []string{"/w", "build", "src", "README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	selCount, totalCount, selSize, totalSize := m.totals()
+	if selCount != 7 || totalCount != 7 || selSize != 335 || totalSize != 335 {
+		t.Errorf("totals = %d/%d entries, %d/%d bytes", selCount, totalCount, selSize, totalSize)
+	}
+
+	m.cursor = 2
+	m.handle(key{name: "right"})
+	if got, want := names(m.rows()), []string{"/w", "build", "src", "util.go", "main.go", "README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rows after expanding src = %v, want %v", got, want)
+	}
+	m.cursor = 3
+	m.handle(key{name: "left"})
+	if m.cursor != 2 {
+		t.Errorf("left on a file should move to its directory, cursor = %d", m.cursor)
+	}
+}
+
+func TestModelToggle(t *testing.T) {
+	m := newModel(plan())
+	src := m.nodes["/w/src"]
+	main := m.nodes["/w/src/main.go"]
+
+	// Keeping a file keeps the directories holding it
+	m.toggle(main)
+	if got, want := paths(m.selection()), []string{"/w/src/util.go", "/w/build/app.log", "/w/build", "/w/README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selection = %v, want %v", got, want)
+	}
+	if src.selCount != 1 || src.selSize != 20 {
+		t.Errorf("src totals = %d entries, %d bytes", src.selCount, src.selSize)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(m.row(src, 60)), "[~]") {
+		t.Errorf("partly selected row = %q", m.row(src, 60))
+	}
+
+	// Selecting it again brings the directories back
+	m.toggle(main)
+	if len(m.selection()) != 7 {
+		t.Errorf("selection = %v, want everything", paths(m.selection()))
+	}
+
+	// Toggling a directory toggles everything beneath it
+	m.toggle(src)
+	if got, want := paths(m.selection()), []string{"/w/build/app.log", "/w/build", "/w/README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selection = %v, want %v", got, want)
+	}
+}
+
+func TestModelFilter(t *testing.T) {
+	m := newModel(plan())
+	m.handle(key{name: "rune", r: '/'})
+	for _, r := range ".go" {
+		m.handle(key{name: "rune", r: r})
+	}
+	if !m.filtering || m.filter != ".go" {
+		t.Fatalf("filter = %q, filtering = %v", m.filter, m.filtering)
+	}
+	// Matches show with the directories leading to them, even collapsed
+	if got, want := names(m.rows()), []string{"/w", "src", "util.go", "main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered rows = %v, want %v", got, want)
+	}
+
+	// Keys for the tree only act once the filter is kept
+	m.handle(key{name: "enter"})
+	m.handle(key{name: "rune", r: 'n'})
+	if got, want := paths(m.selection()), []string{"/w/build/app.log", "/w/build", "/w/README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selection = %v, want %v", got, want)
+	}
+	m.handle(key{name: "rune", r: 'a'})
+	if len(m.selection()) != 7 {
+		t.Errorf("selection = %v, want everything", paths(m.selection()))
+	}
+
+	m.handle(key{name: "rune", r: '/'})
+	m.handle(key{name: "esc"})
+	if m.filter != "" || len(m.rows()) != 4 {
+		t.Errorf("esc should clear the filter, rows = %v", names(m.rows()))
+	}
+	if m.handle(key{name: "rune", r: 'd'}) != actionDone || m.handle(key{name: "rune", r: 'q'}) != actionCancel {
+		t.Error("d should finish the review and q cancel it")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("\x1b[Bj \x1b[5~é\r\x7f"))
+	want := []key{{name: "down"}, {name: "rune", r: 'j'}, {name: "rune", r: ' '}, {name: "pgup"}, {name: "rune", r: 'é'}, {name: "enter"}, {name: "backspace"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys = %v, want %v", got, want)
+	}
+	if got := parseKeys([]byte{0x1b}); !reflect.DeepEqual(got, []key{{name: "esc"}}) {
+		t.Errorf("parseKeys(esc) = %v", got)
+	}
+}
+
+func TestModelSelectEmptyDirs(t *testing.T) {
+	// /w/e holds only the empty directory /w/e/f
+	files := []scanner.FileInfo{
+		{Path: "/w/e/f", IsDir: true},
+		{Path: "/w/e", IsDir: true},
+		{Path: "/w/x", Size: 1},
+		{Path: "/w", IsDir: true},
+	}
+	for i := range files {
+		files[i].Root = "/w"
+	}
+	m := newModel(files)
+
+	m.handle(key{name: "rune", r: 'n'})
+	if got := m.selection(); len(got) != 0 {
+		t.Errorf("selection after none = %v, want nothing", paths(got))
+	}
+	m.handle(key{name: "rune", r: 'a'})
+	if got := m.selection(); len(got) != 4 {
+		t.Errorf("selection after all = %v, want everything", paths(got))
+	}
+
+	// Keeping the empty directory keeps the directories holding it
+	m.toggle(m.nodes["/w/e/f"])
+	if got, want := paths(m.selection()), []string{"/w/x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selection = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,125 @@
+// Package tui is the full-screen review of a deletion plan, where files
+// are picked from a tree instead of being confirmed one by one
+package tui
+
+import (
+	"errors"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"nuke/internal/scanner"
+)
+
+// ErrCancelled is returned when the review is left without deleting
+var ErrCancelled = errors.New("review cancelled")
+
+// Available reports whether the review can run: it needs a terminal on
+// both stdin and stdout
+func Available() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Review shows the planned files as a tree, everything selected, and
+// returns the files left selected in their planned order. The terminal is
+// restored before returning.
+func Review(files []scanner.FileInfo) ([]scanner.FileInfo, error) {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = term.Restore(in, state) }()
+
+	// Draw on the alternate screen with the cursor hidden, so the scrollback
+	// is left as it was
+	_, _ = os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() { _, _ = os.Stdout.WriteString("\x1b[?25h\x1b[?1049l") }()
+
+	m := newModel(files)
+	buf := make([]byte, 256)
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		_, _ = os.Stdout.WriteString(m.render(width, height))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			switch m.handle(k) {
+			case actionDone:
+				return m.selection(), nil
+			case actionCancel:
+				return nil, ErrCancelled
+			}
+		}
+	}
+}
+
+// key is a key press: a named key, or a character when name is "rune"
+type key struct {
+	name string
+	r    rune
+}
+
+// escapes maps the escape sequences of terminals to key names
+var escapes = map[string]string{
+	"\x1b[A": "up", "\x1bOA": "up",
+	"\x1b[B": "down", "\x1bOB": "down",
+	"\x1b[C": "right", "\x1bOC": "right",
+	"\x1b[D": "left", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1bOH": "home", "\x1b[1~": "home",
+	"\x1b[F": "end", "\x1bOF": "end", "\x1b[4~": "end",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdn",
+}
+
+// parseKeys splits what one read of the terminal returned into keys; a
+// paste arrives as many characters at once
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			if len(b) == 1 {
+				return append(keys, key{name: "esc"})
+			}
+			matched := false
+			for seq, name := range escapes {
+				if len(b) >= len(seq) && string(b[:len(seq)]) == seq {
+					keys = append(keys, key{name: name})
+					b = b[len(seq):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// An unknown sequence: drop it rather than read it as text
+				return keys
+			}
+			continue
+		}
+
+		switch b[0] {
+		case '\r', '\n':
+			keys = append(keys, key{name: "enter"})
+		case 0x7f, 0x08:
+			keys = append(keys, key{name: "backspace"})
+		case 0x03:
+			keys = append(keys, key{name: "ctrl-c"})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= ' ' {
+				keys = append(keys, key{name: "rune", r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}